@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"student-api/internal/config"
+	"student-api/internal/database"
+	"student-api/internal/domain"
+	"student-api/internal/importer"
+	"student-api/internal/repository"
+	"student-api/internal/service"
+)
+
+const importUsage = "usage: api import [-dry-run] [-mapping Header=field,...] [-report errors.csv] FILE.csv|FILE.xlsx"
+
+// runImport implements the "api import" subcommand, which upserts students
+// from a spreadsheet straight into the database.
+func runImport(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, importUsage) }
+	dryRun := flags.Bool("dry-run", false, "validate rows without writing them")
+	mappingSpec := flags.String("mapping", "", "extra header=field column mappings")
+	reportPath := flags.String("report", "", "write rejected rows as CSV to this file")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatal(importUsage)
+	}
+	path := flags.Arg(0)
+
+	format, ok := importer.FormatFor("", path)
+	if !ok {
+		log.Fatalf("Unsupported file %q: expected .csv or .xlsx", path)
+	}
+	custom, err := importer.ParseMapping(*mappingSpec)
+	if err != nil {
+		log.Fatalf("Invalid mapping: %v", err)
+	}
+	mapping, err := importer.NewMapping(cfg.ImportColumnMapping)
+	if err == nil {
+		mapping, err = mapping.Merge(custom)
+	}
+	if err != nil {
+		log.Fatalf("Invalid mapping: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	rows, err := importer.NewRows(file, format, mapping)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", path, err)
+	}
+	defer rows.Close()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	studentService := service.NewStudentService(repository.NewMySQLStudentRepository(db))
+
+	ctx := domain.WithActor(context.Background(), "cli:import")
+	report, err := studentService.ImportStudents(ctx, rows, *dryRun)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Dry run:"
+	}
+	fmt.Printf("%s %d rows: %d created, %d updated, %d rejected\n", verb, report.Rows, report.Created, report.Updated, report.Rejected)
+
+	if *reportPath == "" {
+		for _, e := range report.Errors {
+			fmt.Printf("  row %d: %s %s\n", e.Row, e.Field, e.Message)
+		}
+		return
+	}
+	out, err := os.Create(*reportPath)
+	if err != nil {
+		log.Fatalf("Failed to create report: %v", err)
+	}
+	defer out.Close()
+	if err := importer.WriteErrorReport(out, report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	fmt.Printf("Error report written to %s\n", *reportPath)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"student-api/internal/apikey"
+	"student-api/internal/config"
+	"student-api/internal/database"
+	"student-api/internal/repository"
+	"text/tabwriter"
+	"time"
+)
+
+const keysUsage = `usage: api keys create -name NAME -owner OWNER -scopes perm,... [-ttl 2160h]
+       api keys list
+       api keys revoke ID`
+
+// runKeys implements the "api keys" subcommand for managing API keys
+// without going through the admin endpoints.
+func runKeys(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal(keysUsage)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	keys := apikey.NewManager(repository.NewMySQLAPIKeyStore(db))
+
+	ctx := context.Background()
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("keys create", flag.ExitOnError)
+		flags.Usage = func() { fmt.Fprintln(os.Stderr, keysUsage) }
+		name := flags.String("name", "", "what the key is for")
+		owner := flags.String("owner", "", "person or team responsible for the key")
+		scopes := flags.String("scopes", "", "comma separated permissions, e.g. students:read")
+		ttl := flags.Duration("ttl", 0, "lifetime of the key; 0 never expires")
+		flags.Parse(args[1:])
+
+		req := apikey.CreateRequest{Name: *name, Owner: *owner, TTL: *ttl}
+		if *scopes != "" {
+			req.Scopes = strings.Split(*scopes, ",")
+		}
+		key, token, err := keys.Create(ctx, req)
+		if err != nil {
+			log.Fatalf("Failed to create key: %v", err)
+		}
+		fmt.Printf("Created API key %s for %s. Store it now; it cannot be shown again:\n%s\n", key.ID, key.Owner, token)
+	case "list":
+		list, err := keys.List(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list keys: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tOWNER\tSCOPES\tEXPIRES\tLAST USED\tSTATUS")
+		now := time.Now()
+		for _, key := range list {
+			status := "active"
+			switch {
+			case key.RevokedAt != nil:
+				status = "revoked"
+			case key.ExpiresAt != nil && !key.ExpiresAt.After(now):
+				status = "expired"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Owner,
+				strings.Join(key.Scopes, ","), formatTime(key.ExpiresAt), formatTime(key.LastUsedAt), status)
+		}
+		w.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			log.Fatal(keysUsage)
+		}
+		if err := keys.Revoke(ctx, args[1]); err != nil {
+			log.Fatalf("Failed to revoke key: %v", err)
+		}
+		fmt.Printf("Revoked API key %s\n", args[1])
+	default:
+		log.Fatal(keysUsage)
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
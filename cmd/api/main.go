@@ -1,20 +1,44 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"student-api/internal/apikey"
+	"student-api/internal/auth"
 	"student-api/internal/config"
 	"student-api/internal/database"
+	"student-api/internal/domain"
 	"student-api/internal/handler"
+	"student-api/internal/importer"
 	"student-api/internal/logging"
+	"student-api/internal/metrics"
+	"student-api/internal/ratelimit"
 	"student-api/internal/repository"
 	"student-api/internal/service"
+	"student-api/internal/tracing"
+	"student-api/internal/workerpool"
+	"student-api/migrations"
+	"syscall"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
 )
 
+// readinessMaxQueueUsage is the job queue fill ratio at which the instance
+// reports itself not ready.
+const readinessMaxQueueUsage = 0.9
+
+// idempotencyPurgeInterval is how often expired idempotency keys are deleted.
+const idempotencyPurgeInterval = time.Hour
+
 func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig()
@@ -22,36 +46,273 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(cfg, os.Args[2:])
+			return
+		case "import":
+			runImport(cfg, os.Args[2:])
+			return
+		case "keys":
+			runKeys(cfg, os.Args[2:])
+			return
+		default:
+			log.Fatalf("Unknown command %q", os.Args[1])
+		}
+	}
+
+	// Log structured records with personal data redacted; the standard log
+	// package goes through the same handler
+	redactor, err := logging.NewRedactor(cfg.LogRedact, cfg.LogHashKey)
+	if err != nil {
+		log.Fatalf("Invalid LOG_REDACT: %v", err)
+	}
+	sampling, err := logging.NewSampling(cfg.LogSampling)
+	if err != nil {
+		log.Fatalf("Invalid LOG_SAMPLING: %v", err)
+	}
+	baseLogger, err := logging.NewLogger(os.Stdout, cfg.LogFormat, cfg.LogLevel, redactor)
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	slog.SetDefault(baseLogger)
+
+	// Trace requests, queued jobs, service calls and queries
+	stopTracing, err := tracing.Setup(tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		File:        cfg.TracingFile,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	// Initialize database
 	db, err := database.Initialize(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer db.Close()
 
 	// Initialize dependencies
-	logger := logging.NewRequestLogger()
+	logger := logging.NewRequestLogger(baseLogger, sampling, redactor)
 	studentRepo := repository.NewMySQLStudentRepository(db)
-	studentService := service.NewStudentService(studentRepo)
-	studentHandler := handler.NewStudentHandler(studentService, logger)
+	studentService := service.NewTracedStudentService(service.NewStudentService(studentRepo))
+	// Requests go through the permission checks; background jobs use
+	// studentService directly.
+	apiService := studentService
+	policy, err := service.NewPolicy(cfg.RolePermissions)
+	if err != nil {
+		log.Fatalf("Invalid ROLE_PERMISSIONS: %v", err)
+	}
+	if cfg.AuthEnabled {
+		apiService = service.NewAuthorizedStudentService(studentService, policy)
+	}
+	apiKeys := apikey.NewManager(repository.NewMySQLAPIKeyStore(db))
+	appMetrics := metrics.NewMetrics()
+	appMetrics.RegisterDB(db, cfg.DBName)
+
+	pool := workerpool.New(workerpool.Config{
+		Workers:        cfg.WorkerPoolSize,
+		QueueSize:      cfg.MaxJobQueueSize,
+		EnqueueTimeout: cfg.JobEnqueueTimeout,
+		Observer:       appMetrics.NewWorkerPoolObserver("student"),
+	})
+	appMetrics.RegisterWorkerPool("student", pool)
+
+	importMapping, err := importer.NewMapping(cfg.ImportColumnMapping)
+	if err != nil {
+		log.Fatalf("Invalid IMPORT_COLUMN_MAPPING: %v", err)
+	}
+
+	studentHandler := handler.NewStudentHandler(apiService, pool, handler.Config{
+		Timeouts: handler.Timeouts{
+			Request:      cfg.RequestTimeout,
+			Operation:    cfg.OperationTimeout,
+			PerOperation: cfg.OperationTimeouts,
+			Import:       cfg.ImportTimeout,
+		},
+		RequireIfMatch: cfg.RequireIfMatch,
+		ImportMapping:  importMapping,
+	})
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	healthHandler := handler.NewHealthHandler(
+		handler.DatabaseCheck(db),
+		handler.MigrationCheck(migrator),
+		handler.WorkerPoolCheck(pool, readinessMaxQueueUsage),
+	)
 
 	// Set up router
 	router := mux.NewRouter()
+	// Unmatched requests skip router.Use middleware, so they are wrapped here
+	router.NotFoundHandler = logger.LogRequest(appMetrics.Middleware(handler.NotFoundHandler()))
+	router.MethodNotAllowedHandler = logger.LogRequest(appMetrics.Middleware(handler.MethodNotAllowedHandler()))
 
 	// Add logging middleware
 	router.Use(logger.LogRequest)
-	
+
+	// Add metrics middleware
+	router.Use(appMetrics.Middleware)
+
+	// Require a bearer token or API key on API routes, ahead of idempotent
+	// replay so stored responses are only served to authenticated callers
+	if cfg.AuthEnabled {
+		authn := handler.Authenticators{APIKeys: apiKeys}
+		if cfg.JWTEnabled() {
+			keys, err := auth.LoadKeySet(auth.KeySetConfig{
+				JWKSFile:   cfg.JWTJWKSFile,
+				PEMFiles:   cfg.JWTPublicKeyFiles,
+				HMACSecret: cfg.JWTHMACSecret,
+			})
+			if err != nil {
+				log.Fatalf("Failed to load JWT verification keys: %v", err)
+			}
+			authn.JWT = auth.NewVerifier(keys, auth.VerifierConfig{
+				Issuer:    cfg.JWTIssuer,
+				Audience:  cfg.JWTAudience,
+				ClockSkew: cfg.JWTClockSkew,
+			})
+		}
+		router.Use(handler.Authenticate(authn, "/api/"))
+	} else {
+		slog.Warn("authentication is disabled (AUTH_ENABLED=false)")
+	}
+
+	// Meter each client per route before any work is queued
+	if cfg.RateLimitEnabled {
+		limits, err := ratelimit.NewLimits(cfg.RateLimits)
+		if err != nil {
+			log.Fatalf("Invalid RATE_LIMITS: %v", err)
+		}
+		router.Use(handler.RateLimit(ratelimit.NewMemoryStore(), limits, "/api/"))
+	}
+
+	// Replay responses for retried requests carrying an Idempotency-Key;
+	// imports stream their upload and upsert by email, so they are exempt
+	idempotencyStore := repository.NewMySQLIdempotencyStore(db, cfg.IdempotencyTTL, cfg.IdempotencyLockTimeout)
+	router.Use(handler.Idempotency(idempotencyStore, "ImportStudents"))
+
+	// Metrics endpoint
+	router.Handle("/metrics", appMetrics.Handler()).Methods("GET")
+
+	// Health checks
+	router.HandleFunc("/health/live", healthHandler.Live).Methods("GET")
+	router.HandleFunc("/health/ready", healthHandler.Ready).Methods("GET")
+	router.HandleFunc("/health", healthHandler.HealthCheck).Methods("GET")
+
 	// Student routes
-	router.HandleFunc("/api/students", studentHandler.CreateStudent).Methods("POST")
-	router.HandleFunc("/api/students", studentHandler.GetAllStudents).Methods("GET")
-	router.HandleFunc("/api/students/{id:[0-9]+}", studentHandler.GetStudent).Methods("GET")
-	router.HandleFunc("/api/students/{id:[0-9]+}", studentHandler.UpdateStudent).Methods("PUT")
-	router.HandleFunc("/api/students/{id:[0-9]+}", studentHandler.DeleteStudent).Methods("DELETE")
+	router.HandleFunc("/api/students", studentHandler.CreateStudent).Methods("POST").Name("CreateStudent")
+	router.HandleFunc("/api/students:batch", studentHandler.BatchStudents).Methods("POST").Name("BatchStudents")
+	router.HandleFunc("/api/students:import", studentHandler.ImportStudents).Methods("POST").Name("ImportStudents")
+	router.HandleFunc("/api/students/export", studentHandler.ExportStudents).Methods("GET").Name("ExportStudents")
+	router.HandleFunc("/api/students", studentHandler.GetAllStudents).Methods("GET").Name("GetAllStudents")
+	router.HandleFunc("/api/students/{id:[0-9]+}", studentHandler.GetStudent).Methods("GET").Name("GetStudent")
+	router.HandleFunc("/api/students/{id:[0-9]+}", studentHandler.UpdateStudent).Methods("PUT").Name("UpdateStudent")
+	router.HandleFunc("/api/students/{id:[0-9]+}", studentHandler.PatchStudent).Methods("PATCH").Name("PatchStudent")
+	router.HandleFunc("/api/students/{id:[0-9]+}", studentHandler.DeleteStudent).Methods("DELETE").Name("DeleteStudent")
+	router.HandleFunc("/api/students/{id:[0-9]+}:restore", studentHandler.RestoreStudent).Methods("POST").Name("RestoreStudent")
+	router.HandleFunc("/api/students/{id:[0-9]+}/history", studentHandler.GetStudentHistory).Methods("GET").Name("GetStudentHistory")
+
+	// API key administration; keys are only useful with authentication on
+	if cfg.AuthEnabled {
+		apiKeyHandler := handler.NewAPIKeyHandler(apiKeys, policy)
+		router.HandleFunc("/api/admin/api-keys", apiKeyHandler.CreateAPIKey).Methods("POST").Name("CreateAPIKey")
+		router.HandleFunc("/api/admin/api-keys", apiKeyHandler.ListAPIKeys).Methods("GET").Name("ListAPIKeys")
+		router.HandleFunc("/api/admin/api-keys/{id}", apiKeyHandler.RevokeAPIKey).Methods("DELETE").Name("RevokeAPIKey")
+	}
 
 	// Start server
-	addr := fmt.Sprintf(":%s", cfg.ServerPort)
-	log.Printf("Server starting on %s", addr)
-	if err := http.ListenAndServe(addr, router); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.ServerPort),
+		Handler: router,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "addr", srv.Addr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// Wait for a termination signal or a server failure
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go runPeriodically(ctx, idempotencyPurgeInterval, func(ctx context.Context) {
+		if n, err := idempotencyStore.Purge(ctx); err != nil {
+			slog.Error("idempotency key purge failed", "error", err)
+		} else if n > 0 {
+			slog.Info("purged expired idempotency keys", "count", n)
+		}
+	})
+	if cfg.SoftDeleteRetention > 0 {
+		go runPeriodically(ctx, cfg.PurgeInterval, func(ctx context.Context) {
+			ctx = domain.WithActor(ctx, "system:purge")
+			if n, err := studentService.PurgeDeletedStudents(ctx, cfg.SoftDeleteRetention); err != nil {
+				slog.Error("deleted student purge failed", "error", err)
+			} else if n > 0 {
+				slog.Info("purged deleted students", "count", n, "retention", cfg.SoftDeleteRetention.String())
+			}
+		})
+	}
+
+	select {
+	case err := <-serverErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	case <-ctx.Done():
+		slog.Info("shutdown signal received, draining")
+	}
+
+	shutdown(srv, healthHandler, pool, db, stopTracing, cfg.ShutdownReadinessDelay, cfg.ShutdownTimeout)
+}
+
+// shutdown stops the server in dependency order: report unhealthy, stop
+// accepting connections and finish in-flight requests, drain the worker
+// pool, flush pending spans, then close the database. All steps share one
+// deadline.
+func shutdown(srv *http.Server, health *handler.HealthHandler, pool *workerpool.Pool, db io.Closer, stopTracing func(context.Context) error, readinessDelay, timeout time.Duration) {
+	// Keep serving while readiness fails, until the load balancer has had
+	// time to take this instance out of rotation
+	health.SetReady(false)
+	if readinessDelay > 0 {
+		slog.Info("waiting for load balancer to stop routing", "delay", readinessDelay)
+		time.Sleep(readinessDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("HTTP server shutdown failed", "error", err)
+	}
+	if err := pool.Shutdown(ctx); err != nil {
+		slog.Error("worker pool shutdown failed", "error", err)
+	}
+	if err := stopTracing(ctx); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+	}
+	if err := db.Close(); err != nil {
+		slog.Error("database close failed", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// runPeriodically calls fn every interval until ctx is done.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
 	}
 }
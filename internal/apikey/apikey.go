@@ -0,0 +1,209 @@
+// Package apikey issues and verifies the long-lived API keys used by
+// service-to-service clients that cannot obtain JWTs.
+//
+// A key reads "sk_<id>_<secret>". The id is stored in clear and identifies
+// the key in listings and logs; only a SHA-256 hash of the secret is kept.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"student-api/internal/domain"
+	"time"
+	"unicode/utf8"
+)
+
+// Prefix marks API keys so they are recognisable to people and secret
+// scanners.
+const Prefix = "sk_"
+
+// Header is the request header carrying an API key; it may also be sent as
+// "Authorization: ApiKey <key>".
+const Header = "X-API-Key"
+
+// SubjectPrefix starts the principal subject of API key callers, followed
+// by the key id.
+const SubjectPrefix = "apikey:"
+
+// touchInterval limits how often last_used_at is written for a busy key.
+const touchInterval = time.Minute
+
+// InvalidKeyError is returned for every key that fails verification. Its
+// message is safe to return to the client.
+type InvalidKeyError struct {
+	Reason string
+}
+
+func (e *InvalidKeyError) Error() string {
+	return e.Reason
+}
+
+type Key struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Store persists keys with the hash of their secret.
+type Store interface {
+	Create(ctx context.Context, key *Key, hash string) error
+	// Get returns the key and its secret hash, or a domain NotFound error.
+	Get(ctx context.Context, id string) (*Key, string, error)
+	List(ctx context.Context) ([]Key, error)
+	// Revoke marks the key revoked; revoking twice is NotFound.
+	Revoke(ctx context.Context, id string, at time.Time) error
+	// Touch sets last_used_at to at unless it is already after notBefore.
+	Touch(ctx context.Context, id string, at, notBefore time.Time) error
+}
+
+// Manager creates, lists, revokes and verifies keys.
+type Manager struct {
+	store Store
+}
+
+func NewManager(store Store) *Manager {
+	return &Manager{store: store}
+}
+
+// CreateRequest describes a new key. Scopes are student permission names;
+// a zero TTL makes the key never expire.
+type CreateRequest struct {
+	Name   string
+	Owner  string
+	Scopes []string
+	TTL    time.Duration
+}
+
+// Create stores a new key and returns it with the full key string, which is
+// not retrievable later.
+func (m *Manager) Create(ctx context.Context, req CreateRequest) (*Key, string, error) {
+	if fields := validateCreate(req); len(fields) > 0 {
+		return nil, "", &domain.Error{Kind: domain.ErrValidation, Message: "API key request is invalid", Fields: fields}
+	}
+
+	id, err := randomString(9)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	key := &Key{ID: id, Name: req.Name, Owner: req.Owner, Scopes: req.Scopes, CreatedAt: now}
+	if req.TTL > 0 {
+		expiresAt := now.Add(req.TTL)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := m.store.Create(ctx, key, hashSecret(secret)); err != nil {
+		return nil, "", err
+	}
+	return key, Prefix + id + "_" + secret, nil
+}
+
+func validateCreate(req CreateRequest) []domain.FieldError {
+	var fields []domain.FieldError
+	if strings.TrimSpace(req.Name) == "" || utf8.RuneCountInString(req.Name) > 100 {
+		fields = append(fields, domain.FieldError{Field: "name", Message: "is required and must be at most 100 characters"})
+	}
+	if strings.TrimSpace(req.Owner) == "" || utf8.RuneCountInString(req.Owner) > 255 {
+		fields = append(fields, domain.FieldError{Field: "owner", Message: "is required and must be at most 255 characters"})
+	}
+	if len(req.Scopes) == 0 {
+		fields = append(fields, domain.FieldError{Field: "scopes", Message: "must name at least one permission"})
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(domain.Permissions, domain.Permission(scope)) {
+			fields = append(fields, domain.FieldError{Field: "scopes", Message: fmt.Sprintf("unknown permission %q", scope)})
+		}
+	}
+	if req.TTL < 0 {
+		fields = append(fields, domain.FieldError{Field: "ttl", Message: "must not be negative"})
+	}
+	return fields
+}
+
+func (m *Manager) List(ctx context.Context) ([]Key, error) {
+	return m.store.List(ctx)
+}
+
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	return m.store.Revoke(ctx, id, time.Now())
+}
+
+// Authenticate verifies raw and returns the principal of its key, whose
+// scopes act as its permissions.
+func (m *Manager) Authenticate(ctx context.Context, raw string) (*domain.Principal, error) {
+	id, secret, ok := parse(raw)
+	if !ok {
+		return nil, &InvalidKeyError{Reason: "API key is malformed"}
+	}
+	key, hash, err := m.store.Get(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, &InvalidKeyError{Reason: "API key is invalid"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashSecret(secret))) != 1 {
+		return nil, &InvalidKeyError{Reason: "API key is invalid"}
+	}
+
+	now := time.Now()
+	switch {
+	case key.RevokedAt != nil:
+		return nil, &InvalidKeyError{Reason: "API key has been revoked"}
+	case key.ExpiresAt != nil && !key.ExpiresAt.After(now):
+		return nil, &InvalidKeyError{Reason: "API key has expired"}
+	}
+	if err := m.store.Touch(ctx, id, now, now.Add(-touchInterval)); err != nil {
+		return nil, err
+	}
+
+	return &domain.Principal{
+		Subject: SubjectPrefix + key.ID,
+		KeyID:   key.ID,
+		Scopes:  key.Scopes,
+	}, nil
+}
+
+// parse splits "sk_<id>_<secret>"; ids and secrets are base64url, which
+// may itself contain "_", so the id has a fixed length.
+func parse(raw string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(raw, Prefix)
+	if !ok || len(rest) < idLength+2 || rest[idLength] != '_' {
+		return "", "", false
+	}
+	return rest[:idLength], rest[idLength+1:], true
+}
+
+// idLength is the encoded length of the 9 random bytes of an id.
+const idLength = 12
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret needs no salt or stretching: secrets are 256 random bits.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,186 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"student-api/internal/domain"
+	"testing"
+	"time"
+)
+
+// memoryStore keeps keys in a map and applies Touch's condition the way
+// the SQL store does.
+type memoryStore struct {
+	keys    map[string]*Key
+	hashes  map[string]string
+	touches int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{keys: map[string]*Key{}, hashes: map[string]string{}}
+}
+
+func (s *memoryStore) Create(ctx context.Context, key *Key, hash string) error {
+	s.keys[key.ID] = key
+	s.hashes[key.ID] = hash
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id string) (*Key, string, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, "", domain.NewNotFoundError("API key %s not found", id)
+	}
+	copied := *key
+	return &copied, s.hashes[id], nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]Key, error) { return nil, nil }
+
+func (s *memoryStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	key, ok := s.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return domain.NewNotFoundError("API key %s not found", id)
+	}
+	key.RevokedAt = &at
+	return nil
+}
+
+func (s *memoryStore) Touch(ctx context.Context, id string, at, notBefore time.Time) error {
+	key := s.keys[id]
+	if key.LastUsedAt == nil || key.LastUsedAt.Before(notBefore) {
+		key.LastUsedAt = &at
+		s.touches++
+	}
+	return nil
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw    string
+		id     string
+		secret string
+		ok     bool
+	}{
+		{"sk_abcdefghijkl_secret", "abcdefghijkl", "secret", true},
+		{"sk_abc_efghijkl_sec_ret", "abc_efghijkl", "sec_ret", true},
+		{"sk_abcdefghijkl_s", "abcdefghijkl", "s", true},
+		{"sk_abcdefghijkl_", "", "", false},
+		{"sk_abcdefghijk_secret", "", "", false},
+		{"sk_abcdefghijklXsecret", "", "", false},
+		{"pk_abcdefghijkl_secret", "", "", false},
+		{"abcdefghijkl_secret", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		id, secret, ok := parse(tt.raw)
+		if id != tt.id || secret != tt.secret || ok != tt.ok {
+			t.Errorf("parse(%q) = %q, %q, %v, want %q, %q, %v", tt.raw, id, secret, ok, tt.id, tt.secret, tt.ok)
+		}
+	}
+}
+
+func TestCreatedKeyFormat(t *testing.T) {
+	m := NewManager(newMemoryStore())
+	key, raw, err := m.Create(context.Background(), CreateRequest{Name: "reporting", Owner: "data-team", Scopes: []string{"students:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, secret, ok := parse(raw)
+	if !ok || id != key.ID || len(id) != idLength || !strings.HasPrefix(raw, Prefix) {
+		t.Fatalf("key %q does not parse to id %q", raw, key.ID)
+	}
+	if len(secret) != 43 {
+		t.Errorf("secret has %d characters, want 43 (256 bits)", len(secret))
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	m := NewManager(store)
+	create := func(ttl time.Duration) (*Key, string) {
+		t.Helper()
+		key, raw, err := m.Create(ctx, CreateRequest{Name: "reporting", Owner: "data-team", Scopes: []string{"students:read"}, TTL: ttl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key, raw
+	}
+
+	valid, validRaw := create(time.Hour)
+	principal, err := m.Authenticate(ctx, validRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != SubjectPrefix+valid.ID || principal.KeyID != valid.ID ||
+		len(principal.Scopes) != 1 || principal.Scopes[0] != "students:read" {
+		t.Errorf("principal = %+v", principal)
+	}
+
+	revoked, revokedRaw := create(0)
+	if err := m.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	expired, expiredRaw := create(time.Hour)
+	past := time.Now().Add(-time.Second)
+	store.keys[expired.ID].ExpiresAt = &past
+
+	id, secret, _ := parse(validRaw)
+	tests := []struct {
+		name   string
+		raw    string
+		reason string
+	}{
+		{"malformed", "not-a-key", "API key is malformed"},
+		{"unknown id", Prefix + "AAAAAAAAAAAA_" + secret, "API key is invalid"},
+		{"wrong secret", Prefix + id + "_" + secret + "x", "API key is invalid"},
+		{"revoked", revokedRaw, "API key has been revoked"},
+		{"expired", expiredRaw, "API key has expired"},
+	}
+	for _, tt := range tests {
+		_, err := m.Authenticate(ctx, tt.raw)
+		var invalid *InvalidKeyError
+		if !errors.As(err, &invalid) || invalid.Reason != tt.reason {
+			t.Errorf("%s: Authenticate error = %v, want %q", tt.name, err, tt.reason)
+		}
+	}
+}
+
+func TestHashSecret(t *testing.T) {
+	if hashSecret("a") == hashSecret("b") || hashSecret("a") != hashSecret("a") {
+		t.Error("hashSecret is not a deterministic function of the secret")
+	}
+	if h := hashSecret("a"); len(h) != 64 {
+		t.Errorf("hash %q is not hex SHA-256", h)
+	}
+}
+
+func TestAuthenticateTouchesOncePerInterval(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	m := NewManager(store)
+	key, raw, err := m.Create(ctx, CreateRequest{Name: "reporting", Owner: "data-team", Scopes: []string{"students:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := m.Authenticate(ctx, raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.touches != 1 {
+		t.Errorf("last used written %d times in a burst, want once", store.touches)
+	}
+
+	earlier := time.Now().Add(-touchInterval - time.Second)
+	store.keys[key.ID].LastUsedAt = &earlier
+	if _, err := m.Authenticate(ctx, raw); err != nil {
+		t.Fatal(err)
+	}
+	if store.touches != 2 || !store.keys[key.ID].LastUsedAt.After(earlier) {
+		t.Errorf("last used not written after the interval: %d writes", store.touches)
+	}
+}
@@ -0,0 +1,120 @@
+// Package auth verifies the credentials API requests carry and turns them
+// into a domain.Principal.
+package auth
+
+import (
+	"errors"
+	"strings"
+	"student-api/internal/domain"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms lists the JWT signing algorithms accepted.
+var Algorithms = []string{"RS256", "ES256", "HS256"}
+
+// InvalidTokenError is returned for every token that fails verification. Its
+// message is safe to return to the client.
+type InvalidTokenError struct {
+	Reason string
+	Err    error
+}
+
+func (e *InvalidTokenError) Error() string {
+	return e.Reason
+}
+
+func (e *InvalidTokenError) Unwrap() error {
+	return e.Err
+}
+
+// VerifierConfig holds the claims a token must carry. ClockSkew is the
+// leeway allowed when checking exp, nbf and iat.
+type VerifierConfig struct {
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
+}
+
+// claims are the token claims read into the principal besides the
+// registered ones: "roles" is a list and "scope" space separated, as in
+// OAuth 2.0.
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+	Scope string   `json:"scope,omitempty"`
+}
+
+type Verifier struct {
+	keys   KeySource
+	parser *jwt.Parser
+}
+
+func NewVerifier(keys KeySource, cfg VerifierConfig) *Verifier {
+	return &Verifier{
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(Algorithms),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithLeeway(cfg.ClockSkew),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+	}
+}
+
+// Verify checks the token's signature and claims and returns its principal.
+func (v *Verifier) Verify(raw string) (*domain.Principal, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(raw, &c, func(token *jwt.Token) (interface{}, error) {
+		keys, err := v.keys.VerificationKeys(token)
+		if err != nil {
+			return nil, err
+		}
+		set := jwt.VerificationKeySet{}
+		for _, key := range keys {
+			set.Keys = append(set.Keys, key)
+		}
+		return set, nil
+	})
+	if err != nil {
+		return nil, &InvalidTokenError{Reason: tokenErrorReason(err), Err: err}
+	}
+	if c.Subject == "" {
+		return nil, &InvalidTokenError{Reason: "token has no subject", Err: jwt.ErrTokenRequiredClaimMissing}
+	}
+
+	principal := &domain.Principal{
+		Subject: c.Subject,
+		Issuer:  c.Issuer,
+		Roles:   c.Roles,
+	}
+	if c.Scope != "" {
+		principal.Scopes = strings.Fields(c.Scope)
+	}
+	return principal, nil
+}
+
+// tokenErrorReason describes why verification failed without echoing
+// token contents.
+func tokenErrorReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "token is malformed"
+	case errors.Is(err, jwt.ErrTokenUnverifiable), errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return "token signature is invalid"
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token has expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return "token is not valid yet"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "token is missing a required claim"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "token issuer is not accepted"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "token audience is not accepted"
+	}
+	return "token is invalid"
+}
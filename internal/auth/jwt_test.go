@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://auth.example.com/"
+	testAudience = "student-api"
+	testSecret   = "test-hmac-secret"
+)
+
+// validClaims returns claims that pass verification; tests change one.
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"roles": []string{"teacher"},
+		"scope": "students:read students:update",
+	}
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func newTestVerifier(t *testing.T, cfg KeySetConfig) *Verifier {
+	t.Helper()
+	keys, err := LoadKeySet(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewVerifier(keys, VerifierConfig{Issuer: testIssuer, Audience: testAudience, ClockSkew: time.Minute})
+}
+
+func TestVerifyPrincipal(t *testing.T) {
+	v := newTestVerifier(t, KeySetConfig{HMACSecret: testSecret})
+	principal, err := v.Verify(signHS256(t, validClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != "user-1" || principal.Issuer != testIssuer {
+		t.Errorf("principal = %+v", principal)
+	}
+	if !reflect.DeepEqual(principal.Roles, []string{"teacher"}) {
+		t.Errorf("roles = %v", principal.Roles)
+	}
+	if !reflect.DeepEqual(principal.Scopes, []string{"students:read", "students:update"}) {
+		t.Errorf("scopes = %v", principal.Scopes)
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		change func(jwt.MapClaims)
+		reason string
+	}{
+		{"expired", func(c jwt.MapClaims) { c["exp"] = now.Add(-2 * time.Minute).Unix() }, "token has expired"},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }, "token is missing a required claim"},
+		{"not valid yet", func(c jwt.MapClaims) { c["nbf"] = now.Add(2 * time.Minute).Unix() }, "token is not valid yet"},
+		{"issued in the future", func(c jwt.MapClaims) { c["iat"] = now.Add(2 * time.Minute).Unix() }, "token is not valid yet"},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com/" }, "token issuer is not accepted"},
+		{"missing issuer", func(c jwt.MapClaims) { delete(c, "iss") }, "token is missing a required claim"},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-api" }, "token audience is not accepted"},
+		{"missing subject", func(c jwt.MapClaims) { delete(c, "sub") }, "token has no subject"},
+	}
+	v := newTestVerifier(t, KeySetConfig{HMACSecret: testSecret})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.change(claims)
+			_, err := v.Verify(signHS256(t, claims))
+			invalid, ok := err.(*InvalidTokenError)
+			if !ok {
+				t.Fatalf("Verify error = %v, want *InvalidTokenError", err)
+			}
+			if invalid.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", invalid.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyWithinClockSkew(t *testing.T) {
+	v := newTestVerifier(t, KeySetConfig{HMACSecret: testSecret})
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	claims["nbf"] = time.Now().Add(30 * time.Second).Unix()
+	if _, err := v.Verify(signHS256(t, claims)); err != nil {
+		t.Errorf("Verify within clock skew: %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	pemFile := filepath.Join(t.TempDir(), "key-1.pem")
+	if err := os.WriteFile(pemFile, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v := newTestVerifier(t, KeySetConfig{PEMFiles: map[string]string{"key-1": pemFile}})
+
+	signES256 := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, validClaims())
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		raw, err := token.SignedString(ecKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	if _, err := v.Verify(signES256("key-1")); err != nil {
+		t.Errorf("ES256 token with matching kid: %v", err)
+	}
+	if _, err := v.Verify(signES256("")); err != nil {
+		t.Errorf("ES256 token without kid: %v", err)
+	}
+
+	// The public key must not be usable as an HMAC secret
+	confused, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString(pemBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodES256, validClaims()).SignedString(otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, raw := range map[string]string{
+		"unknown kid":           signES256("key-2"),
+		"public key as HMAC":    confused,
+		"alg none":              unsigned,
+		"signed by another key": forged,
+		"malformed":             "not.a.token",
+	} {
+		if _, err := v.Verify(raw); err == nil {
+			t.Errorf("%s: Verify succeeded, want error", name)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySource supplies the keys a token's signature is checked against. It
+// is called with the parsed but unverified token, so implementations can
+// select keys by the header's kid and alg.
+type KeySource interface {
+	VerificationKeys(token *jwt.Token) ([]interface{}, error)
+}
+
+// verificationKey is a public key (*rsa.PublicKey or *ecdsa.PublicKey) or
+// HMAC secret ([]byte), optionally bound to a key id and algorithm.
+type verificationKey struct {
+	kid string
+	alg string
+	key interface{}
+}
+
+// KeySet is a static KeySource. A token with a kid is only checked against
+// the key with that id; one without a kid against every key.
+type KeySet struct {
+	keys []verificationKey
+}
+
+// KeySetConfig names the places keys are loaded from; any combination may
+// be set.
+type KeySetConfig struct {
+	// JWKSFile is the path of a JSON Web Key Set document.
+	JWKSFile string
+	// PEMFiles maps key ids to PEM files holding a public key or
+	// certificate.
+	PEMFiles map[string]string
+	// HMACSecret enables HS256 tokens signed with this shared secret.
+	HMACSecret string
+}
+
+// LoadKeySet reads all keys named by cfg.
+func LoadKeySet(cfg KeySetConfig) (*KeySet, error) {
+	set := &KeySet{}
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		set.keys = append(set.keys, keys...)
+	}
+	for kid, path := range cfg.PEMFiles {
+		key, err := loadPEM(path)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		set.keys = append(set.keys, verificationKey{kid: kid, key: key})
+	}
+	if cfg.HMACSecret != "" {
+		set.keys = append(set.keys, verificationKey{alg: "HS256", key: []byte(cfg.HMACSecret)})
+	}
+	if len(set.keys) == 0 {
+		return nil, errors.New("no verification keys configured")
+	}
+	return set, nil
+}
+
+func (s *KeySet) VerificationKeys(token *jwt.Token) ([]interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	var keys []interface{}
+	for _, k := range s.keys {
+		if kid != "" && k.kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != token.Method.Alg() {
+			continue
+		}
+		if !keyFitsMethod(k.key, token.Method) {
+			continue
+		}
+		keys = append(keys, k.key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key for kid %q and alg %s", kid, token.Method.Alg())
+	}
+	return keys, nil
+}
+
+// keyFitsMethod keeps a key from being used with another algorithm family,
+// e.g. an RSA public key as an HMAC secret.
+func keyFitsMethod(key interface{}, method jwt.SigningMethod) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodRSA)
+		return ok
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case []byte:
+		_, ok := method.(*jwt.SigningMethodHMAC)
+		return ok
+	}
+	return false
+}
+
+func loadPEM(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported key type %T", path, key)
+}
+
+// jwk holds the members of a JSON Web Key (RFC 7517) used for RSA, EC P-256
+// and symmetric keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func loadJWKS(path string) ([]verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var keys []verificationKey
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %d: %w", path, i, err)
+		}
+		keys = append(keys, verificationKey{kid: k.Kid, alg: k.Alg, key: key})
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on P-256")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("invalid symmetric key")
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
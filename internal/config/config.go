@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +17,81 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	ServerPort string
+	// AutoMigrate applies pending schema migrations on startup.
+	AutoMigrate bool
+	// ShutdownTimeout bounds how long shutdown waits for in-flight requests
+	// and queued jobs to drain.
+	ShutdownTimeout time.Duration
+	// ShutdownReadinessDelay is how long readiness fails before the server
+	// stops accepting connections, so load balancers can notice first.
+	ShutdownReadinessDelay time.Duration
+	// Worker pool sizing; see internal/workerpool.
+	WorkerPoolSize    int
+	MaxJobQueueSize   int
+	JobEnqueueTimeout time.Duration
+	// RequestTimeout bounds how long a handler waits for its job;
+	// OperationTimeout bounds the service and database work inside it and
+	// OperationTimeouts overrides it per handler operation.
+	RequestTimeout    time.Duration
+	OperationTimeout  time.Duration
+	OperationTimeouts map[string]time.Duration
+	// ImportTimeout bounds imports, which run outside the worker pool.
+	ImportTimeout time.Duration
+	// RequireIfMatch rejects PUT and PATCH requests without an If-Match
+	// header with 428 Precondition Required.
+	RequireIfMatch bool
+	// ImportColumnMapping maps spreadsheet headers to student fields for
+	// imports, on top of the built-in aliases, e.g. "Surname=lastName".
+	ImportColumnMapping map[string]string
+	// IdempotencyTTL is how long responses to requests with an
+	// Idempotency-Key are kept for replay; IdempotencyLockTimeout is how
+	// long an unfinished request blocks its key.
+	IdempotencyTTL         time.Duration
+	IdempotencyLockTimeout time.Duration
+	// SoftDeleteRetention is how long soft-deleted students are kept before
+	// the purge job removes them for good; zero disables purging.
+	// PurgeInterval is how often the purge job runs.
+	SoftDeleteRetention time.Duration
+	PurgeInterval       time.Duration
+	// AuthEnabled requires a JWT bearer token or an API key on /api routes.
+	// JWTs are accepted when any of JWTJWKSFile, JWTPublicKeyFiles (key id
+	// to PEM path) and JWTHMACSecret is set, and must carry JWTIssuer and
+	// JWTAudience; exp, nbf and iat are checked with JWTClockSkew leeway.
+	AuthEnabled       bool
+	JWTJWKSFile       string
+	JWTPublicKeyFiles map[string]string
+	JWTHMACSecret     string
+	JWTIssuer         string
+	JWTAudience       string
+	JWTClockSkew      time.Duration
+	// RolePermissions maps token roles to the student permissions they
+	// grant; see internal/service.Policy.
+	RolePermissions map[string][]string
+	// RateLimits maps route names (the handler operation, e.g.
+	// "ImportStudents") to token bucket limits such as "10/1m"; routes
+	// without an entry share the "default" limit. RateLimitEnabled turns
+	// limiting off altogether.
+	RateLimitEnabled bool
+	RateLimits       map[string]string
+	// LogFormat is "json" or "text"; LogLevel is debug, info, warn or
+	// error.
+	LogFormat string
+	LogLevel  string
+	// LogRedact maps log attribute keys to redactions (hash, mask,
+	// truncate, drop or keep), on top of defaults that cover emails, names
+	// and client IPs. LogHashKey keys the hashes.
+	LogRedact  map[string]string
+	LogHashKey string
+	// LogSampling maps route names to the share of their requests logged,
+	// from 0 to 1; errors are always logged.
+	LogSampling map[string]string
+	// TracingExporter is "otlp", "file" or "none"; the OTLP endpoint comes
+	// from the standard OTEL_EXPORTER_OTLP_* variables. TracingFile is
+	// where the file exporter writes, and TracingSampleRatio the share of
+	// new traces recorded.
+	TracingExporter    string
+	TracingFile        string
+	TracingSampleRatio float64
 }
 
 func LoadConfig() (*Config, error) {
@@ -31,10 +109,244 @@ func LoadConfig() (*Config, error) {
 		ServerPort: os.Getenv("SERVER_PORT"),
 	}
 
+	config.AutoMigrate, err = getEnvBool("DB_AUTO_MIGRATE", true)
+	if err != nil {
+		return nil, err
+	}
+	config.ShutdownTimeout, err = getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.ShutdownReadinessDelay, err = getEnvDuration("SHUTDOWN_READINESS_DELAY", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.WorkerPoolSize, err = getEnvInt("WORKER_POOL_SIZE", 50)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxJobQueueSize, err = getEnvInt("MAX_JOB_QUEUE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	config.JobEnqueueTimeout, err = getEnvDuration("JOB_ENQUEUE_TIMEOUT", 250*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	config.RequestTimeout, err = getEnvDuration("REQUEST_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.OperationTimeout, err = getEnvDuration("OPERATION_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.OperationTimeouts, err = getEnvDurationMap("OPERATION_TIMEOUTS")
+	if err != nil {
+		return nil, err
+	}
+	config.ImportTimeout, err = getEnvDuration("IMPORT_TIMEOUT", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	config.RequireIfMatch, err = getEnvBool("REQUIRE_IF_MATCH", false)
+	if err != nil {
+		return nil, err
+	}
+	config.ImportColumnMapping, err = getEnvMap("IMPORT_COLUMN_MAPPING")
+	if err != nil {
+		return nil, err
+	}
+	config.IdempotencyTTL, err = getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	config.IdempotencyLockTimeout, err = getEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	config.SoftDeleteRetention, err = getEnvDuration("SOFT_DELETE_RETENTION", 365*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	config.PurgeInterval, err = getEnvDuration("PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	config.AuthEnabled, err = getEnvBool("AUTH_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	config.JWTJWKSFile = os.Getenv("JWT_JWKS_FILE")
+	config.JWTPublicKeyFiles, err = getEnvMap("JWT_PUBLIC_KEY_FILES")
+	if err != nil {
+		return nil, err
+	}
+	config.JWTHMACSecret = os.Getenv("JWT_HMAC_SECRET")
+	config.JWTIssuer = os.Getenv("JWT_ISSUER")
+	config.JWTAudience = os.Getenv("JWT_AUDIENCE")
+	config.JWTClockSkew, err = getEnvDuration("JWT_CLOCK_SKEW", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	config.RolePermissions, err = getEnvListMap("ROLE_PERMISSIONS",
+		"teacher=students:read,registrar=students:read|students:create|students:update,admin=*")
+	if err != nil {
+		return nil, err
+	}
+	config.RateLimitEnabled, err = getEnvBool("RATE_LIMIT_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	config.RateLimits, err = parseMap("RATE_LIMITS", getEnv("RATE_LIMITS",
+		"default=300/1m,BatchStudents=30/1m,ImportStudents=10/1m,ExportStudents=10/1m"))
+	if err != nil {
+		return nil, err
+	}
+	config.LogFormat = getEnv("LOG_FORMAT", "json")
+	config.LogLevel = getEnv("LOG_LEVEL", "info")
+	config.LogRedact, err = parseMap("LOG_REDACT", "email=hash,first_name=mask,last_name=mask,owner=hash,client_ip=truncate")
+	if err != nil {
+		return nil, err
+	}
+	redact, err := getEnvMap("LOG_REDACT")
+	if err != nil {
+		return nil, err
+	}
+	for key, action := range redact {
+		config.LogRedact[key] = action
+	}
+	config.LogHashKey = os.Getenv("LOG_HASH_KEY")
+	config.LogSampling, err = getEnvMap("LOG_SAMPLING")
+	if err != nil {
+		return nil, err
+	}
+	config.TracingExporter = getEnv("TRACING_EXPORTER", "none")
+	config.TracingFile = getEnv("TRACING_FILE", "traces.jsonl")
+	config.TracingSampleRatio, err = getEnvFloat("TRACING_SAMPLE_RATIO", 1)
+	if err != nil {
+		return nil, err
+	}
+	if config.JWTEnabled() && (config.JWTIssuer == "" || config.JWTAudience == "") {
+		return nil, fmt.Errorf("JWT_ISSUER and JWT_AUDIENCE are required when JWT keys are configured")
+	}
+
 	return config, nil
 }
 
 func (c *Config) GetDSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&clientFoundRows=true",
 		c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName)
 }
+
+// JWTEnabled reports whether bearer tokens are accepted.
+func (c *Config) JWTEnabled() bool {
+	return c.AuthEnabled && (c.JWTJWKSFile != "" || len(c.JWTPublicKeyFiles) > 0 || c.JWTHMACSecret != "")
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// getEnvMap parses a comma separated list of name=value pairs.
+func getEnvMap(key string) (map[string]string, error) {
+	return parseMap(key, os.Getenv(key))
+}
+
+func parseMap(key, value string) (map[string]string, error) {
+	result := make(map[string]string)
+	if value == "" {
+		return result, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid %s entry %q", key, pair)
+		}
+		result[name] = raw
+	}
+	return result, nil
+}
+
+// getEnvListMap parses a comma separated list of name=value pairs whose
+// values are "|" separated lists, e.g. "teacher=students:read,admin=*".
+func getEnvListMap(key, fallback string) (map[string][]string, error) {
+	values, err := parseMap(key, getEnv(key, fallback))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]string, len(values))
+	for name, raw := range values {
+		result[name] = strings.Split(raw, "|")
+	}
+	return result, nil
+}
+
+// getEnvDurationMap parses a comma separated list of name=duration pairs,
+// e.g. "GetAllStudents=20s,CreateStudent=5s".
+func getEnvDurationMap(key string) (map[string]time.Duration, error) {
+	values, err := getEnvMap(key)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Duration, len(values))
+	for name, raw := range values {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", key, name+"="+raw, err)
+		}
+		result[name] = d
+	}
+	return result, nil
+}
@@ -1,18 +1,43 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"student-api/internal/config"
+	"student-api/migrations"
 )
 
-// Initialize sets up the database and required tables
+// Initialize sets up the database and, unless disabled, applies pending migrations
 func Initialize(cfg *config.Config) (*sql.DB, error) {
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.AutoMigrate {
+		migrator, err := NewMigrator(db, migrations.FS)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error applying migrations: %w", err)
+		}
+	}
+
+	slog.Info("database initialized")
+	return db, nil
+}
+
+// Open creates the database if it doesn't exist and connects to it
+func Open(cfg *config.Config) (*sql.DB, error) {
 	// First connect without database name to create the database if it doesn't exist
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/",
 		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort)
-	
+
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to MySQL: %w", err)
@@ -31,30 +56,5 @@ func Initialize(cfg *config.Config) (*sql.DB, error) {
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	// Create students table if it doesn't exist
-	err = createTables(db)
-	if err != nil {
-		return nil, fmt.Errorf("error creating tables: %w", err)
-	}
-
-	log.Println("Database and tables initialized successfully")
 	return db, nil
 }
-
-func createTables(db *sql.DB) error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS students (
-		id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
-		first_name VARCHAR(50) NOT NULL,
-		last_name VARCHAR(50) NOT NULL,
-		email VARCHAR(100) NOT NULL UNIQUE,
-		age INT NOT NULL,
-		grade DECIMAL(4,2) NOT NULL,
-		created_at TIMESTAMP NOT NULL,
-		updated_at TIMESTAMP NOT NULL,
-		INDEX idx_email (email)
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`
-
-	_, err := db.Exec(createTableSQL)
-	return err
-}
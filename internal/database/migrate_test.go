@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{"single without semicolon", "SELECT 1", []string{"SELECT 1"}},
+		{"several", "SELECT 1;\nSELECT 2;\n\n;", []string{"SELECT 1", "SELECT 2"}},
+		{
+			"semicolons in quotes",
+			"INSERT INTO t VALUES ('a;b', \"c;d\");\nSELECT `x;y` FROM t;",
+			[]string{"INSERT INTO t VALUES ('a;b', \"c;d\")", "SELECT `x;y` FROM t"},
+		},
+		{
+			"escaped quote",
+			`INSERT INTO t VALUES ('it\'s; fine');SELECT 2`,
+			[]string{`INSERT INTO t VALUES ('it\'s; fine')`, "SELECT 2"},
+		},
+		{
+			"comments",
+			"-- leading; comment\nSELECT 1; # trailing; comment\n/* block;\ncomment */ SELECT 2;",
+			[]string{"SELECT 1", "SELECT 2"},
+		},
+		{"only comments", "-- nothing here;\n/* or here; */", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_add_index.up.sql":         {Data: []byte("CREATE INDEX i ON t (a);")},
+		"002_create_table.up.sql":      {Data: []byte("CREATE TABLE t (a INT);")},
+		"002_create_table.down.sql":    {Data: []byte("DROP TABLE t;")},
+		"README.md":                    {Data: []byte("not a migration")},
+		"003_not_sql.up.txt":           {Data: []byte("ignored")},
+		"subdir/004_nested.up.sql":     {Data: []byte("ignored")},
+		"001_first_migration.up.sql":   {Data: []byte("SELECT 1;")},
+		"001_first_migration.down.sql": {Data: []byte("SELECT 2;")},
+	}
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []uint64
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	if want := []uint64{1, 2, 10}; !reflect.DeepEqual(versions, want) {
+		t.Fatalf("versions = %v, want %v", versions, want)
+	}
+
+	m := migrations[1]
+	if m.Name != "create_table" || m.Up != "CREATE TABLE t (a INT);" || m.Down != "DROP TABLE t;" {
+		t.Errorf("migration 2 = %+v", m)
+	}
+	sum := sha256.Sum256([]byte("CREATE TABLE t (a INT);"))
+	if want := hex.EncodeToString(sum[:]); m.Checksum != want {
+		t.Errorf("checksum = %s, want %s", m.Checksum, want)
+	}
+	if migrations[2].Down != "" {
+		t.Errorf("migration 10 has down %q, want none", migrations[2].Down)
+	}
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"duplicate version": {
+			"001_one.up.sql": {Data: []byte("SELECT 1;")},
+			"001_two.up.sql": {Data: []byte("SELECT 2;")},
+		},
+		"missing up": {
+			"001_one.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range tests {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: loadMigrations succeeded, want error", name)
+		}
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	m := &Migrator{migrations: []Migration{
+		{Version: 1, Name: "one", Checksum: "aaa"},
+		{Version: 2, Name: "two", Checksum: "bbb"},
+	}}
+
+	if err := m.verifyChecksums(map[uint64]migrationRecord{1: {checksum: "aaa"}}); err != nil {
+		t.Errorf("matching checksums: %v", err)
+	}
+	if err := m.verifyChecksums(map[uint64]migrationRecord{2: {checksum: "changed"}}); err == nil {
+		t.Error("modified migration passed the checksum check")
+	}
+}
+
+// noMigrationsTableDB is a database/sql driver for a database without a
+// schema_migrations table. It fails every query with MySQL's missing table
+// error and counts the statements executed.
+type noMigrationsTableDB struct {
+	execs atomic.Int32
+}
+
+func (d *noMigrationsTableDB) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d *noMigrationsTableDB) Driver() driver.Driver                        { return nil }
+
+func (d *noMigrationsTableDB) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+func (d *noMigrationsTableDB) Close() error              { return nil }
+func (d *noMigrationsTableDB) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (d *noMigrationsTableDB) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	d.execs.Add(1)
+	return driver.RowsAffected(0), nil
+}
+
+func (d *noMigrationsTableDB) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, &mysql.MySQLError{Number: mysqlErrNoSuchTable, Message: "Table 'students.schema_migrations' doesn't exist"}
+}
+
+func TestPendingDoesNotCreateMigrationsTable(t *testing.T) {
+	fake := &noMigrationsTableDB{}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+	m, err := NewMigrator(db, fstest.MapFS{
+		"001_one.up.sql": {Data: []byte("SELECT 1;")},
+		"002_two.up.sql": {Data: []byte("SELECT 2;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := m.Pending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != 2 {
+		t.Errorf("pending = %d, want 2", pending)
+	}
+	if n := fake.execs.Load(); n != 0 {
+		t.Errorf("Pending executed %d statements, want none", n)
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Error kinds. Match them with errors.Is; the concrete *Error carries a
+// message that is safe to show to API clients.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("service unavailable")
+	// ErrPreconditionFailed means the stored version of a record no longer
+	// matches the one the client based its change on.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrForbidden means the caller lacks the permission an operation
+	// requires.
+	ErrForbidden = errors.New("forbidden")
+)
+
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NewNotFoundError(format string, args ...interface{}) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewConflictError(cause error, format string, args ...interface{}) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...), Err: cause}
+}
+
+func NewValidationError(cause error, format string, args ...interface{}) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...), Err: cause}
+}
+
+func NewPreconditionFailedError(format string, args ...interface{}) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewForbiddenError(permission Permission) error {
+	return &Error{Kind: ErrForbidden, Message: fmt.Sprintf("missing permission %s", permission)}
+}
+
+func NewUnavailableError(cause error, format string, args ...interface{}) error {
+	return &Error{Kind: ErrUnavailable, Message: fmt.Sprintf(format, args...), Err: cause}
+}
+
+// ErrorMessage returns the client-safe message of a domain error, or ""
+// if err is not one.
+func ErrorMessage(err error) string {
+	var de *Error
+	if errors.As(err, &de) {
+		return de.Message
+	}
+	return ""
+}
+
+// emailPattern matches email addresses inside free text.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// ReplaceEmails returns s with every email address in it replaced by
+// replace. Database errors quote the values they reject, so the text of an
+// error wrapping one may contain a student's email.
+func ReplaceEmails(s string, replace func(email string) string) string {
+	return emailPattern.ReplaceAllStringFunc(s, replace)
+}
+
+// FieldError describes why a single input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors returns the per-field details of a validation error, if any.
+func FieldErrors(err error) []FieldError {
+	var de *Error
+	if errors.As(err, &de) {
+		return de.Fields
+	}
+	return nil
+}
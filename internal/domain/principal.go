@@ -0,0 +1,49 @@
+package domain
+
+import "context"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Issuer  string
+	Roles   []string
+	Scopes  []string
+	// KeyID is set when the caller authenticated with an API key.
+	KeyID string
+}
+
+// Permission names a class of student operations a principal may perform.
+type Permission string
+
+const (
+	PermissionRead   Permission = "students:read"
+	PermissionCreate Permission = "students:create"
+	PermissionUpdate Permission = "students:update"
+	PermissionDelete Permission = "students:delete"
+	// PermissionReadDeleted allows reading soft-deleted students
+	// (includeDeleted).
+	PermissionReadDeleted Permission = "students:read-deleted"
+	// PermissionPurge allows permanently removing soft-deleted students.
+	PermissionPurge Permission = "students:purge"
+	// PermissionManageKeys allows creating, listing and revoking API keys.
+	PermissionManageKeys Permission = "apikeys:manage"
+	// PermissionAll grants every permission.
+	PermissionAll Permission = "*"
+)
+
+// Permissions lists every permission except PermissionAll.
+var Permissions = []Permission{
+	PermissionRead,
+	PermissionCreate,
+	PermissionUpdate,
+	PermissionDelete,
+	PermissionReadDeleted,
+	PermissionPurge,
+	PermissionManageKeys,
+}
+
+// Authorizer checks the permissions of the principal in a request context,
+// failing with ErrForbidden naming the first one missing.
+type Authorizer interface {
+	Authorize(ctx context.Context, permissions ...Permission) error
+}
@@ -6,28 +6,151 @@ import (
 )
 
 type Student struct {
-	ID        uint      `json:"id"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Email     string    `json:"email"`
-	Age       int       `json:"age"`
-	Grade     float64   `json:"grade"`
+	ID        uint    `json:"id"`
+	FirstName string  `json:"firstName"`
+	LastName  string  `json:"lastName"`
+	Email     string  `json:"email"`
+	Age       int     `json:"age"`
+	Grade     float64 `json:"grade"`
+	// Version is incremented on every write and exposed as the ETag.
+	Version   uint64    `json:"-"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// DeletedAt is set once the student is soft-deleted.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
+// StudentChanges lists the columns a partial update writes; nil fields are
+// left unchanged.
+type StudentChanges struct {
+	FirstName *string
+	LastName  *string
+	Email     *string
+	Age       *int
+	Grade     *float64
+}
+
+func (c StudentChanges) IsEmpty() bool {
+	return c.FirstName == nil && c.LastName == nil && c.Email == nil && c.Age == nil && c.Grade == nil
+}
+
+// DiffStudent returns the writable fields that differ between from and to.
+func DiffStudent(from, to *Student) StudentChanges {
+	var c StudentChanges
+	if from.FirstName != to.FirstName {
+		c.FirstName = &to.FirstName
+	}
+	if from.LastName != to.LastName {
+		c.LastName = &to.LastName
+	}
+	if from.Email != to.Email {
+		c.Email = &to.Email
+	}
+	if from.Age != to.Age {
+		c.Age = &to.Age
+	}
+	if from.Grade != to.Grade {
+		c.Grade = &to.Grade
+	}
+	return c
+}
+
+// Apply copies the changed fields onto s.
+func (c StudentChanges) Apply(s *Student) {
+	if c.FirstName != nil {
+		s.FirstName = *c.FirstName
+	}
+	if c.LastName != nil {
+		s.LastName = *c.LastName
+	}
+	if c.Email != nil {
+		s.Email = *c.Email
+	}
+	if c.Age != nil {
+		s.Age = *c.Age
+	}
+	if c.Grade != nil {
+		s.Grade = *c.Grade
+	}
+}
+
+// StudentPatch transforms the JSON document of a student's writable fields
+// (see Student.WritableJSON) into its patched form.
+type StudentPatch func(document []byte) ([]byte, error)
+
 type StudentRepository interface {
-	Create(student *Student) error
-	GetByID(id uint) (*Student, error)
-	GetAll() ([]Student, error)
-	Update(student *Student) error
-	Delete(id uint) error
+	Create(ctx context.Context, student *Student) error
+	// CreateMany inserts all students with multi-row INSERTs, assigning
+	// their IDs. If a statement fails none of its rows are stored; outside
+	// a transaction no row is stored at all and every ID is left zero.
+	CreateMany(ctx context.Context, students []*Student) error
+	// GetByID returns a live student, or also a soft-deleted one with
+	// includeDeleted.
+	GetByID(ctx context.Context, id uint, includeDeleted bool) (*Student, error)
+	GetAll(ctx context.Context, query StudentQuery) (*StudentPage, error)
+	// Stream calls fn for every student matching filter, in sort order,
+	// reading from a database cursor rather than loading all rows. It stops
+	// at the first error fn returns. The *Student is reused between calls.
+	Stream(ctx context.Context, filter StudentFilter, sort []SortField, fn func(*Student) error) error
+	// Update overwrites the student. A non-zero student.Version makes the
+	// write conditional on the stored version matching it; on success
+	// Version holds the new version.
+	Update(ctx context.Context, student *Student) error
+	// UpdateFields writes only the given columns if the stored version is
+	// still version, returning the new updated_at timestamp and version.
+	UpdateFields(ctx context.Context, id uint, version uint64, changes StudentChanges) (time.Time, uint64, error)
+	// Delete soft-deletes the student by setting deleted_at.
+	Delete(ctx context.Context, id uint) error
+	// Restore clears deleted_at of a soft-deleted student.
+	Restore(ctx context.Context, id uint) error
+	// PurgeDeleted permanently removes students soft-deleted before cutoff.
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error)
+	// ExistingEmails returns the ids of the students using any of emails,
+	// keyed by lower-cased email.
+	ExistingEmails(ctx context.Context, emails []string) (map[string]uint, error)
+	// UpsertByEmail inserts the students, overwriting the stored student
+	// with the same email instead where one exists. On success each
+	// student holds the stored record.
+	UpsertByEmail(ctx context.Context, students []*Student) error
+	// History returns a page of the student's audit trail, newest first.
+	// Entries outlive the student itself.
+	History(ctx context.Context, id uint, query HistoryQuery) (*HistoryPage, error)
+	// AuditSince returns the audit entries recorded after since, newest
+	// first.
+	AuditSince(ctx context.Context, id uint, since time.Time) ([]StudentAuditEntry, error)
+	// InTransaction runs fn with a repository bound to one database
+	// transaction, committing if fn returns nil and rolling back otherwise.
+	InTransaction(ctx context.Context, fn func(repo StudentRepository) error) error
 }
 
 type StudentService interface {
 	CreateStudent(ctx context.Context, student *Student) error
-	GetStudent(ctx context.Context, id uint) (*Student, error)
-	GetAllStudents(ctx context.Context) ([]Student, error)
+	GetStudent(ctx context.Context, id uint, includeDeleted bool) (*Student, error)
+	GetAllStudents(ctx context.Context, query StudentQuery) (*StudentPage, error)
+	// ExportStudents streams every student matching the query's filter and
+	// sort to fn; paging fields are ignored.
+	ExportStudents(ctx context.Context, query StudentQuery, fn func(*Student) error) error
+	// UpdateStudent replaces the student, requiring the stored version to
+	// equal student.Version unless it is zero.
 	UpdateStudent(ctx context.Context, student *Student) error
+	// PatchStudent applies patch to the stored student. A non-zero
+	// ifVersion must match the stored version.
+	PatchStudent(ctx context.Context, id uint, ifVersion uint64, patch StudentPatch) (*Student, error)
 	DeleteStudent(ctx context.Context, id uint) error
+	// GetStudentAsOf returns the student as it was at asOf, rebuilt from the
+	// current record and the audit trail.
+	GetStudentAsOf(ctx context.Context, id uint, asOf time.Time, includeDeleted bool) (*Student, error)
+	// GetStudentHistory returns a page of the student's audit trail.
+	GetStudentHistory(ctx context.Context, id uint, query HistoryQuery) (*HistoryPage, error)
+	// RestoreStudent undoes a soft delete.
+	RestoreStudent(ctx context.Context, id uint) (*Student, error)
+	// PurgeDeletedStudents permanently removes students that have been
+	// soft-deleted for longer than retention.
+	PurgeDeletedStudents(ctx context.Context, retention time.Duration) (int64, error)
+	// ExecuteBatch runs ops in order and returns one result per operation.
+	// The error is only set when the batch as a whole could not run.
+	ExecuteBatch(ctx context.Context, mode BatchMode, ops []BatchOperation) ([]BatchResult, error)
+	// ImportStudents validates every row and upserts the valid ones by
+	// email. With dryRun nothing is written.
+	ImportStudents(ctx context.Context, rows StudentRows, dryRun bool) (*ImportReport, error)
 }
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+	// AuditPurge records the permanent removal of a soft-deleted student.
+	AuditPurge AuditAction = "purge"
+)
+
+// AnonymousActor is recorded for writes made without a known actor.
+const AnonymousActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor returns a context whose writes are attributed to actor in the
+// audit trail.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or AnonymousActor.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// FieldChange holds a field's value before and after a write, in its JSON
+// representation; nil means the field had no value (e.g. before a create).
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// StudentAuditEntry records one write to a student. Version is the
+// student's version after the write.
+type StudentAuditEntry struct {
+	ID        uint64                 `json:"id"`
+	StudentID uint                   `json:"studentId"`
+	Action    AuditAction            `json:"action"`
+	Actor     string                 `json:"actor"`
+	TraceID   string                 `json:"traceId,omitempty"`
+	Version   uint64                 `json:"version"`
+	Changes   map[string]FieldChange `json:"changes"`
+	At        time.Time              `json:"at"`
+}
+
+// HistoryQuery pages through a student's audit trail, newest first.
+// BeforeID continues after the last entry of the previous page.
+type HistoryQuery struct {
+	Limit    int
+	BeforeID uint64
+}
+
+// HistoryPage is one page of audit entries, newest first.
+type HistoryPage struct {
+	Entries []StudentAuditEntry
+	HasMore bool
+}
+
+// auditedFields are the student fields tracked by the audit trail.
+var auditedFields = []string{"firstName", "lastName", "email", "age", "grade", "updatedAt", "deletedAt"}
+
+// PersonalAuditFields are the audited fields that identify a student. Their
+// values are scrubbed from the trail when the student is purged.
+var PersonalAuditFields = []string{"firstName", "lastName", "email"}
+
+func auditFields(s *Student) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	fields := map[string]interface{}{
+		"firstName": s.FirstName,
+		"lastName":  s.LastName,
+		"email":     s.Email,
+		"age":       s.Age,
+		"grade":     s.Grade,
+		"updatedAt": s.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		"deletedAt": nil,
+	}
+	if s.DeletedAt != nil {
+		fields["deletedAt"] = s.DeletedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return fields
+}
+
+// AuditChanges returns the fields that differ between before and after.
+// before is nil for a create and after is nil for a purge.
+func AuditChanges(before, after *Student) map[string]FieldChange {
+	from, to := auditFields(before), auditFields(after)
+	changes := make(map[string]FieldChange)
+	for _, field := range auditedFields {
+		if from[field] != to[field] {
+			changes[field] = FieldChange{From: from[field], To: to[field]}
+		}
+	}
+	return changes
+}
+
+// ScrubPersonalData blanks the values of the personal fields the entry
+// changed, keeping the record that they changed.
+func (e *StudentAuditEntry) ScrubPersonalData() {
+	for _, field := range PersonalAuditFields {
+		if _, ok := e.Changes[field]; ok {
+			e.Changes[field] = FieldChange{}
+		}
+	}
+}
+
+// Revert sets the fields the entry changed on s back to their values from
+// before the write, and its version to the one the write replaced.
+func (e *StudentAuditEntry) Revert(s *Student) error {
+	previous := make(map[string]interface{}, len(e.Changes))
+	for field, change := range e.Changes {
+		previous[field] = change.From
+	}
+	document, err := json.Marshal(previous)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(document, s); err != nil {
+		return err
+	}
+	s.Version = e.Version - 1
+	return nil
+}
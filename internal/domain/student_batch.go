@@ -0,0 +1,80 @@
+package domain
+
+import "errors"
+
+// MaxBatchOperations caps the number of operations in one batch request.
+const MaxBatchOperations = 1000
+
+// BatchMode selects how a batch reacts to a failing operation.
+type BatchMode string
+
+const (
+	// BatchAtomic runs every operation in one transaction; any failure
+	// rolls back the whole batch.
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort applies each operation independently and reports
+	// failures per item.
+	BatchBestEffort BatchMode = "best-effort"
+)
+
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchOperation is one entry of a batch. Student is set for create and
+// update, ID for update and delete. A non-zero Version makes an update
+// conditional, as If-Match does for PUT. Err is set when the entry could not
+// be decoded; it is reported as the item's result without being executed.
+type BatchOperation struct {
+	Op      BatchOp
+	ID      uint
+	Version uint64
+	Student *Student
+	Err     error
+}
+
+// BatchResult is the outcome of the operation at the same index. Student is
+// the stored record for create and update.
+type BatchResult struct {
+	Op      BatchOp
+	ID      uint
+	Student *Student
+	Err     error
+}
+
+// ErrBatchAborted marks operations of an atomic batch that were not applied
+// because another operation failed.
+var ErrBatchAborted = errors.New("batch aborted")
+
+func newBatchAbortedError() error {
+	return &Error{Kind: ErrBatchAborted, Message: "not applied: another operation in the atomic batch failed"}
+}
+
+// AbortBatch replaces the result of every operation that did not fail
+// itself with ErrBatchAborted, dropping ids assigned to rolled-back creates.
+func AbortBatch(results []BatchResult) {
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		id := result.ID
+		if result.Op == BatchCreate {
+			id = 0
+		}
+		results[i] = BatchResult{Op: result.Op, ID: id, Err: newBatchAbortedError()}
+	}
+}
+
+// IsItemError reports whether err is caused by the operation's own data
+// (and so belongs in its result) rather than by the database being
+// unreachable or the request being cancelled.
+func IsItemError(err error) bool {
+	return errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrConflict) ||
+		errors.Is(err, ErrValidation) ||
+		errors.Is(err, ErrPreconditionFailed)
+}
@@ -0,0 +1,55 @@
+package domain
+
+// ImportRow is one data row of an import file. Row is the 1-based line
+// number in the file (the header is row 1). Fields lists problems found while
+// converting the cells; Student is nil if there were any.
+type ImportRow struct {
+	Row     int
+	Student *Student
+	Fields  []FieldError
+}
+
+// StudentRows iterates over the rows of an import file. Next returns io.EOF
+// after the last row; any other error aborts the import.
+type StudentRows interface {
+	Next() (*ImportRow, error)
+}
+
+// ImportRowError explains why a row was rejected.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport summarises an import. In a dry run Created and Updated count
+// the rows that would have been written.
+type ImportReport struct {
+	DryRun   bool             `json:"dryRun"`
+	Rows     int              `json:"rows"`
+	Created  int              `json:"created"`
+	Updated  int              `json:"updated"`
+	Rejected int              `json:"rejected"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// RejectRow records the field errors of a rejected row.
+func (r *ImportReport) RejectRow(row int, fields []FieldError) {
+	r.Rejected++
+	for _, f := range fields {
+		r.Errors = append(r.Errors, ImportRowError{Row: row, Field: f.Field, Message: f.Message})
+	}
+}
+
+// RejectRowError records a row the database refused.
+func (r *ImportReport) RejectRowError(row int, err error) {
+	if fields := FieldErrors(err); len(fields) > 0 {
+		r.RejectRow(row, fields)
+		return
+	}
+	message := ErrorMessage(err)
+	if message == "" {
+		message = "row could not be stored"
+	}
+	r.RejectRow(row, []FieldError{{Message: message}})
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// ErrMalformedJSON is returned when a student document is not a JSON object.
+var ErrMalformedJSON = errors.New("request body must be a JSON object")
+
+// studentPayload is the writable subset of a student accepted from clients.
+// Pointers distinguish omitted fields from zero values.
+type studentPayload struct {
+	FirstName *string  `json:"firstName"`
+	LastName  *string  `json:"lastName"`
+	Email     *string  `json:"email"`
+	Age       *int     `json:"age"`
+	Grade     *float64 `json:"grade"`
+}
+
+var writableStudentFields = map[string]bool{
+	"firstName": true, "lastName": true, "email": true, "age": true, "grade": true,
+}
+
+var readOnlyStudentFields = map[string]bool{
+	"id": true, "createdAt": true, "updatedAt": true, "deletedAt": true,
+}
+
+// ParseStudentJSON decodes a full student representation. Structural
+// problems (unknown, read-only, mistyped or missing fields) and rule
+// violations are reported together as one validation error.
+func ParseStudentJSON(data []byte) (*Student, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || raw == nil {
+		return nil, ErrMalformedJSON
+	}
+
+	var fieldErrs []FieldError
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch {
+		case readOnlyStudentFields[key]:
+			fieldErrs = append(fieldErrs, FieldError{Field: key, Message: "is read-only"})
+		case !writableStudentFields[key]:
+			fieldErrs = append(fieldErrs, FieldError{Field: key, Message: "is not a known field"})
+		}
+	}
+
+	var payload studentPayload
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&payload); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return nil, ErrMalformedJSON
+		}
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   typeErr.Field,
+			Message: "must be " + jsonTypeName(typeErr.Type.Kind().String()),
+		})
+	}
+
+	student := &Student{}
+	if payload.FirstName != nil {
+		student.FirstName = *payload.FirstName
+	}
+	if payload.LastName != nil {
+		student.LastName = *payload.LastName
+	}
+	if payload.Email != nil {
+		student.Email = *payload.Email
+	}
+	if payload.Age != nil {
+		student.Age = *payload.Age
+	} else if _, present := raw["age"]; !present {
+		fieldErrs = append(fieldErrs, FieldError{Field: "age", Message: "is required"})
+	}
+	if payload.Grade != nil {
+		student.Grade = *payload.Grade
+	} else if _, present := raw["grade"]; !present {
+		fieldErrs = append(fieldErrs, FieldError{Field: "grade", Message: "is required"})
+	}
+	student.Normalize()
+
+	// Only add rule violations for fields without a structural error.
+	reported := make(map[string]bool, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		reported[fe.Field] = true
+	}
+	for _, fe := range ValidateStudent(student) {
+		if !reported[fe.Field] {
+			fieldErrs = append(fieldErrs, fe)
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		return nil, NewStudentValidationError(fieldErrs)
+	}
+	return student, nil
+}
+
+// WritableJSON encodes the client-writable fields of the student, the
+// document that PATCH requests are applied to.
+func (s *Student) WritableJSON() ([]byte, error) {
+	return json.Marshal(studentPayload{
+		FirstName: &s.FirstName,
+		LastName:  &s.LastName,
+		Email:     &s.Email,
+		Age:       &s.Age,
+		Grade:     &s.Grade,
+	})
+}
+
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "an integer"
+	case "float32", "float64":
+		return "a number"
+	}
+	return "a " + kind
+}
@@ -0,0 +1,71 @@
+package domain
+
+const (
+	DefaultStudentPageSize = 20
+	MaxStudentPageSize     = 100
+)
+
+// SortableStudentFields lists the JSON field names that can be used with sort=.
+var SortableStudentFields = []string{
+	"id", "firstName", "lastName", "email", "age", "grade", "createdAt", "updatedAt",
+}
+
+func IsSortableStudentField(field string) bool {
+	for _, f := range SortableStudentFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+type StudentFilter struct {
+	MinAge         *int
+	MaxAge         *int
+	MinGrade       *float64
+	MaxGrade       *float64
+	LastNamePrefix string
+	EmailDomain    string
+	// IncludeDeleted also returns soft-deleted students.
+	IncludeDeleted bool
+}
+
+// StudentQuery describes which students to list and how to page through them.
+// Offset paging uses Limit/Offset; keyset paging uses AfterID/BeforeID and is
+// only valid when the results are ordered by id alone.
+type StudentQuery struct {
+	Filter   StudentFilter
+	Sort     []SortField
+	Limit    int
+	Offset   int
+	AfterID  uint
+	BeforeID uint
+}
+
+// IsKeyset reports whether the query pages by id cursor rather than offset.
+func (q StudentQuery) IsKeyset() bool {
+	return q.AfterID != 0 || q.BeforeID != 0
+}
+
+// SortsByIDOnly reports whether the ordering allows keyset pagination on id.
+func (q StudentQuery) SortsByIDOnly() bool {
+	return len(q.Sort) == 0 || (len(q.Sort) == 1 && q.Sort[0].Field == "id")
+}
+
+// IDDescending reports whether ids are returned in descending order.
+func (q StudentQuery) IDDescending() bool {
+	return len(q.Sort) == 1 && q.Sort[0].Field == "id" && q.Sort[0].Desc
+}
+
+type StudentPage struct {
+	Students []Student
+	Total    int64
+	// HasMore is true when further rows exist beyond this page in the
+	// direction of travel (towards BeforeID for backwards keyset queries).
+	HasMore bool
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// Field limits mirror the students table columns.
+const (
+	MaxNameLength  = 50  // first_name, last_name VARCHAR(50)
+	MaxEmailLength = 100 // email VARCHAR(100)
+	MinStudentAge  = 3
+	MaxStudentAge  = 120
+	MinGrade       = 0
+	MaxGrade       = 99.99 // grade DECIMAL(4,2)
+	GradeDecimals  = 2
+)
+
+// Normalize trims surrounding whitespace from the text fields.
+func (s *Student) Normalize() {
+	s.FirstName = strings.TrimSpace(s.FirstName)
+	s.LastName = strings.TrimSpace(s.LastName)
+	s.Email = strings.TrimSpace(s.Email)
+}
+
+// ValidateStudent checks every writable field and returns all problems
+// found rather than stopping at the first one.
+func ValidateStudent(s *Student) []FieldError {
+	var errs []FieldError
+	errs = appendNameErrors(errs, "firstName", s.FirstName)
+	errs = appendNameErrors(errs, "lastName", s.LastName)
+	errs = appendEmailErrors(errs, s.Email)
+
+	if s.Age < MinStudentAge || s.Age > MaxStudentAge {
+		errs = append(errs, FieldError{
+			Field:   "age",
+			Message: fmt.Sprintf("must be between %d and %d", MinStudentAge, MaxStudentAge),
+		})
+	}
+
+	switch {
+	case math.IsNaN(s.Grade) || s.Grade < MinGrade || s.Grade > MaxGrade:
+		errs = append(errs, FieldError{
+			Field:   "grade",
+			Message: fmt.Sprintf("must be between %d and %.2f", MinGrade, MaxGrade),
+		})
+	case !hasMaxDecimals(s.Grade, GradeDecimals):
+		errs = append(errs, FieldError{
+			Field:   "grade",
+			Message: fmt.Sprintf("must have at most %d decimal places", GradeDecimals),
+		})
+	}
+	return errs
+}
+
+// NewStudentValidationError wraps field errors in a domain validation error.
+func NewStudentValidationError(fields []FieldError) error {
+	return &Error{Kind: ErrValidation, Message: "student data is invalid", Fields: fields}
+}
+
+func appendNameErrors(errs []FieldError, field, value string) []FieldError {
+	switch {
+	case value == "":
+		return append(errs, FieldError{Field: field, Message: "is required"})
+	case utf8.RuneCountInString(value) > MaxNameLength:
+		return append(errs, FieldError{Field: field, Message: fmt.Sprintf("must be at most %d characters", MaxNameLength)})
+	case strings.ContainsFunc(value, isControl):
+		return append(errs, FieldError{Field: field, Message: "must not contain control characters"})
+	}
+	return errs
+}
+
+func appendEmailErrors(errs []FieldError, value string) []FieldError {
+	if value == "" {
+		return append(errs, FieldError{Field: "email", Message: "is required"})
+	}
+	if utf8.RuneCountInString(value) > MaxEmailLength {
+		return append(errs, FieldError{Field: "email", Message: fmt.Sprintf("must be at most %d characters", MaxEmailLength)})
+	}
+	// mail.ParseAddress implements the RFC 5322 address grammar; reject
+	// display-name forms like "John <john@example.com>" by requiring the
+	// parsed address to be the whole input.
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || addr.Name != "" {
+		return append(errs, FieldError{Field: "email", Message: "must be a valid email address"})
+	}
+	if _, domain, _ := strings.Cut(value, "@"); !strings.Contains(domain, ".") {
+		return append(errs, FieldError{Field: "email", Message: "must be a valid email address"})
+	}
+	return errs
+}
+
+func hasMaxDecimals(v float64, decimals int) bool {
+	scale := math.Pow10(decimals)
+	scaled := v * scale
+	return math.Abs(scaled-math.Round(scaled)) < 1e-6
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func validStudent() *Student {
+	return &Student{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Age: 20, Grade: 91.5}
+}
+
+// fieldsOf returns the fields named by errs, in order.
+func fieldsOf(errs []FieldError) []string {
+	var fields []string
+	for _, fe := range errs {
+		fields = append(fields, fe.Field)
+	}
+	return fields
+}
+
+func TestValidateStudent(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Student)
+		fields []string
+	}{
+		{"valid", func(s *Student) {}, nil},
+		{"youngest", func(s *Student) { s.Age = MinStudentAge }, nil},
+		{"oldest", func(s *Student) { s.Age = MaxStudentAge }, nil},
+		{"too young", func(s *Student) { s.Age = MinStudentAge - 1 }, []string{"age"}},
+		{"too old", func(s *Student) { s.Age = MaxStudentAge + 1 }, []string{"age"}},
+		{"lowest grade", func(s *Student) { s.Grade = MinGrade }, nil},
+		{"highest grade", func(s *Student) { s.Grade = MaxGrade }, nil},
+		{"negative grade", func(s *Student) { s.Grade = -0.01 }, []string{"grade"}},
+		{"grade above column", func(s *Student) { s.Grade = 100 }, []string{"grade"}},
+		{"NaN grade", func(s *Student) { s.Grade = math.NaN() }, []string{"grade"}},
+		{"two decimals", func(s *Student) { s.Grade = 85.25 }, nil},
+		{"binary rounding of two decimals", func(s *Student) { s.Grade = 0.1 + 0.2 }, nil},
+		{"three decimals", func(s *Student) { s.Grade = 85.125 }, []string{"grade"}},
+		{"missing first name", func(s *Student) { s.FirstName = "" }, []string{"firstName"}},
+		{"longest name", func(s *Student) { s.LastName = strings.Repeat("é", MaxNameLength) }, nil},
+		{"name too long", func(s *Student) { s.LastName = strings.Repeat("é", MaxNameLength+1) }, []string{"lastName"}},
+		{"control character", func(s *Student) { s.FirstName = "Ada\nLovelace" }, []string{"firstName"}},
+		{"missing email", func(s *Student) { s.Email = "" }, []string{"email"}},
+		{"email without at", func(s *Student) { s.Email = "ada.example.com" }, []string{"email"}},
+		{"email with display name", func(s *Student) { s.Email = "Ada <ada@example.com>" }, []string{"email"}},
+		{"email without domain dot", func(s *Student) { s.Email = "ada@localhost" }, []string{"email"}},
+		{"email too long", func(s *Student) { s.Email = strings.Repeat("a", MaxEmailLength) + "@example.com" }, []string{"email"}},
+		{"plus address", func(s *Student) { s.Email = "ada+school@example.co.uk" }, nil},
+		{"all problems", func(s *Student) { *s = Student{Age: 2, Grade: 1.001} }, []string{"firstName", "lastName", "email", "age", "grade"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validStudent()
+			tt.change(s)
+			if got := fieldsOf(ValidateStudent(s)); !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestParseStudentJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"valid", `{"firstName":" Ada ","lastName":"Lovelace","email":"ada@example.com","age":20,"grade":91.5}`, nil},
+		{"missing fields", `{}`, []string{"age", "grade", "firstName", "lastName", "email"}},
+		{"read-only id", `{"id":7,"firstName":"Ada","lastName":"Lovelace","email":"ada@example.com","age":20,"grade":91.5}`, []string{"id"}},
+		{"read-only timestamps", `{"createdAt":"2025-01-01T00:00:00Z","updatedAt":"2025-01-01T00:00:00Z","firstName":"Ada","lastName":"Lovelace","email":"ada@example.com","age":20,"grade":91.5}`, []string{"createdAt", "updatedAt"}},
+		{"unknown field", `{"nickname":"Ada","firstName":"Ada","lastName":"Lovelace","email":"ada@example.com","age":20,"grade":91.5}`, []string{"nickname"}},
+		{"mistyped age", `{"firstName":"Ada","lastName":"Lovelace","email":"ada@example.com","age":"20","grade":91.5}`, []string{"age"}},
+		{"fractional age", `{"firstName":"Ada","lastName":"Lovelace","email":"ada@example.com","age":20.5,"grade":91.5}`, []string{"age"}},
+		{"rule and structure", `{"id":1,"firstName":"Ada","lastName":"Lovelace","email":"nope","age":200,"grade":91.5}`, []string{"id", "email", "age"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			student, err := ParseStudentJSON([]byte(tt.body))
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("ParseStudentJSON: %v (%v)", err, FieldErrors(err))
+				}
+				if student.FirstName != "Ada" || student.Age != 20 || student.Grade != 91.5 {
+					t.Errorf("student = %+v", student)
+				}
+				return
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("error = %v, want a validation error", err)
+			}
+			if got := fieldsOf(FieldErrors(err)); !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestParseStudentJSONMalformed(t *testing.T) {
+	for _, body := range []string{``, `null`, `[]`, `"student"`, `{"firstName":`} {
+		if _, err := ParseStudentJSON([]byte(body)); err != ErrMalformedJSON {
+			t.Errorf("ParseStudentJSON(%q) error = %v, want ErrMalformedJSON", body, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"student-api/internal/apikey"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHandler serves the admin endpoints managing API keys. Every route
+// requires the apikeys:manage permission, and a new key's scopes must be
+// held by its creator.
+type APIKeyHandler struct {
+	keys  *apikey.Manager
+	authz domain.Authorizer
+}
+
+func NewAPIKeyHandler(keys *apikey.Manager, authz domain.Authorizer) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys, authz: authz}
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Owner  string   `json:"owner"`
+	Scopes []string `json:"scopes"`
+	// TTL is a Go duration such as "2160h"; empty keys never expire.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// CreatedAPIKey is the key as stored plus the full key string, which is
+// only ever returned here.
+type CreatedAPIKey struct {
+	apikey.Key
+	Token string `json:"key"`
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "CreateAPIKey")
+	if !h.authorize(w, r, "CreateAPIKey") {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxStudentBodyBytes))
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		logger.Info("invalid request body", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	// A key may only carry permissions the caller holds, so managing keys
+	// does not grant every other permission too. Unknown scopes are left to
+	// validation.
+	var scopes []domain.Permission
+	for _, scope := range req.Scopes {
+		if slices.Contains(domain.Permissions, domain.Permission(scope)) {
+			scopes = append(scopes, domain.Permission(scope))
+		}
+	}
+	if err := h.authz.Authorize(r.Context(), scopes...); err != nil {
+		logError(r.Context(), logger, err)
+		writeError(w, r, err)
+		return
+	}
+	create := apikey.CreateRequest{Name: req.Name, Owner: req.Owner, Scopes: req.Scopes}
+	if req.TTL != "" {
+		if create.TTL, err = time.ParseDuration(req.TTL); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "ttl must be a duration such as 720h")
+			return
+		}
+	}
+
+	key, token, err := h.keys.Create(r.Context(), create)
+	if err != nil {
+		logError(r.Context(), logger, err)
+		writeError(w, r, err)
+		return
+	}
+
+	logger.Info("API key created", "key_id", key.ID, "owner", key.Owner)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreatedAPIKey{Key: *key, Token: token})
+}
+
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "ListAPIKeys")
+	if !h.authorize(w, r, "ListAPIKeys") {
+		return
+	}
+
+	keys, err := h.keys.List(r.Context())
+	if err != nil {
+		logError(r.Context(), logger, err)
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]apikey.Key{"data": keys})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "RevokeAPIKey")
+	if !h.authorize(w, r, "RevokeAPIKey") {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if err := h.keys.Revoke(r.Context(), id); err != nil {
+		logError(r.Context(), logger, err)
+		writeError(w, r, err)
+		return
+	}
+
+	logger.Info("API key revoked", "key_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIKeyHandler) authorize(w http.ResponseWriter, r *http.Request, operation string) bool {
+	if err := h.authz.Authorize(r.Context(), domain.PermissionManageKeys); err != nil {
+		logError(r.Context(), logging.Operation(r.Context(), operation), err)
+		writeError(w, r, err)
+		return false
+	}
+	return true
+}
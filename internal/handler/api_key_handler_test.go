@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"student-api/internal/apikey"
+	"student-api/internal/domain"
+	"testing"
+)
+
+// grantedPermissions authorizes exactly the permissions it holds.
+type grantedPermissions map[domain.Permission]bool
+
+func (g grantedPermissions) Authorize(ctx context.Context, permissions ...domain.Permission) error {
+	for _, permission := range permissions {
+		if !g[permission] {
+			return domain.NewForbiddenError(permission)
+		}
+	}
+	return nil
+}
+
+// createdKeys is an apikey.Store that only records created keys.
+type createdKeys struct {
+	apikey.Store
+	keys []*apikey.Key
+}
+
+func (s *createdKeys) Create(ctx context.Context, key *apikey.Key, hash string) error {
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	reader := grantedPermissions{domain.PermissionManageKeys: true, domain.PermissionRead: true}
+	tests := []struct {
+		name   string
+		scopes string
+		status int
+	}{
+		{"held scope", `["students:read"]`, http.StatusCreated},
+		{"held manage permission", `["students:read","apikeys:manage"]`, http.StatusCreated},
+		{"scope not held", `["students:read","students:purge"]`, http.StatusForbidden},
+		{"unknown scope", `["students:everything"]`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &createdKeys{}
+			h := NewAPIKeyHandler(apikey.NewManager(store), reader)
+
+			body := `{"name":"reporting","owner":"data-team","scopes":` + tt.scopes + `}`
+			rec := httptest.NewRecorder()
+			h.CreateAPIKey(rec, httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", strings.NewReader(body)))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if created := len(store.keys) == 1; created != (tt.status == http.StatusCreated) {
+				t.Errorf("%d keys created", len(store.keys))
+			}
+		})
+	}
+}
+
+func TestCreateAPIKeyNeedsManagePermission(t *testing.T) {
+	store := &createdKeys{}
+	h := NewAPIKeyHandler(apikey.NewManager(store), grantedPermissions{domain.PermissionRead: true})
+	rec := httptest.NewRecorder()
+	h.CreateAPIKey(rec, httptest.NewRequest(http.MethodPost, "/api/admin/api-keys",
+		strings.NewReader(`{"name":"reporting","owner":"data-team","scopes":["students:read"]}`)))
+	if rec.Code != http.StatusForbidden || len(store.keys) != 0 {
+		t.Errorf("status = %d with %d keys created, want 403 and none", rec.Code, len(store.keys))
+	}
+}
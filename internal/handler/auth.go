@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"student-api/internal/apikey"
+	"student-api/internal/auth"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+)
+
+const authRealm = "student-api"
+
+// Authenticators are the credentials Authenticate accepts; a nil field
+// disables that kind.
+type Authenticators struct {
+	// JWT verifies "Authorization: Bearer" tokens.
+	JWT *auth.Verifier
+	// APIKeys verifies keys sent in X-API-Key or "Authorization: ApiKey".
+	APIKeys *apikey.Manager
+}
+
+// Authenticate requires valid credentials on requests whose path starts
+// with pathPrefix; other paths (health checks, metrics) pass through. The
+// caller's principal is added to the request context and becomes the actor
+// of audited writes. Failures get 401 with a WWW-Authenticate challenge as
+// in RFC 6750.
+func Authenticate(authn Authenticators, pathPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, pathPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			logger := logging.Operation(r.Context(), "Authenticate")
+
+			scheme, credential := credentials(r)
+			var verify func(ctx context.Context, credential string) (*domain.Principal, error)
+			switch {
+			case scheme == "Bearer" && authn.JWT != nil:
+				verify = func(_ context.Context, token string) (*domain.Principal, error) {
+					return authn.JWT.Verify(token)
+				}
+			case scheme == "ApiKey" && authn.APIKeys != nil:
+				verify = authn.APIKeys.Authenticate
+			default:
+				logger.Info("missing credentials")
+				w.Header().Set("WWW-Authenticate", authn.challenge(""))
+				writeProblem(w, r, http.StatusUnauthorized, "A bearer token or API key is required")
+				return
+			}
+
+			principal, err := verify(r.Context(), credential)
+			if err != nil {
+				var invalidToken *auth.InvalidTokenError
+				var invalidKey *apikey.InvalidKeyError
+				if !errors.As(err, &invalidToken) && !errors.As(err, &invalidKey) {
+					// The credential could not be checked, e.g. the key
+					// store is down; that is not the client's fault.
+					logError(r.Context(), logger, err)
+					writeError(w, r, err)
+					return
+				}
+				reason := err.Error()
+				if invalidToken != nil {
+					logger.Info("token rejected", "error", errors.Unwrap(err))
+				} else {
+					logger.Info("API key rejected", "reason", reason)
+				}
+				w.Header().Set("WWW-Authenticate", authn.challenge(reason))
+				writeProblem(w, r, http.StatusUnauthorized, "The credentials were rejected: "+reason)
+				return
+			}
+
+			ctx := logging.AddPrincipalToContext(r.Context(), principal)
+			ctx = domain.WithActor(ctx, principal.Subject)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// credentials returns the scheme ("Bearer" or "ApiKey") and credential of
+// the request; an X-API-Key header counts as the ApiKey scheme.
+func credentials(r *http.Request) (scheme, credential string) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, credential, _ = strings.Cut(header, " ")
+		switch {
+		case strings.EqualFold(scheme, "Bearer"):
+			scheme = "Bearer"
+		case strings.EqualFold(scheme, "ApiKey"):
+			scheme = "ApiKey"
+		}
+		return scheme, strings.TrimSpace(credential)
+	}
+	if key := r.Header.Get(apikey.Header); key != "" {
+		return "ApiKey", strings.TrimSpace(key)
+	}
+	return "", ""
+}
+
+// challenge lists the accepted schemes, describing the error on each when
+// credentials were rejected.
+func (a Authenticators) challenge(reason string) string {
+	params := fmt.Sprintf("realm=%q", authRealm)
+	if reason != "" {
+		params += fmt.Sprintf(", error=\"invalid_token\", error_description=%q", reason)
+	}
+	var schemes []string
+	if a.JWT != nil {
+		schemes = append(schemes, "Bearer "+params)
+	}
+	if a.APIKeys != nil {
+		schemes = append(schemes, "ApiKey "+params)
+	}
+	return strings.Join(schemes, ", ")
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"student-api/internal/domain"
+)
+
+// statusForError maps a domain error kind to its HTTP status code.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrBatchAborted):
+		return http.StatusFailedDependency
+	case errors.Is(err, domain.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// logError logs a failed operation, at error level for server failures and
+// at info level for errors caused by the request.
+func logError(ctx context.Context, logger *slog.Logger, err error) {
+	level := slog.LevelInfo
+	if statusForError(err) >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logger.Log(ctx, level, "operation failed", "error", err)
+}
+
+// writeError responds with a problem document for err. Only domain error
+// messages are sent to the client; anything else becomes a generic 500.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := errorProblem(err).forRequest(r)
+	if p.Status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "5")
+	}
+	p.Write(w)
+}
+
+// errorProblem describes err without request details, as used for the
+// per-item results of a batch.
+func errorProblem(err error) *Problem {
+	status := statusForError(err)
+	detail := domain.ErrorMessage(err)
+	if status == http.StatusInternalServerError {
+		detail = ""
+	}
+	p := statusProblem(status, detail)
+	p.Errors = domain.FieldErrors(err)
+	return p
+}
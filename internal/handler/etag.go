@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must be * or a single ETag")
+
+// studentETag formats a student's row version as a strong entity tag.
+func studentETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// parseIfMatch returns the version an If-Match header requires. Zero means
+// the write is unconditional: the header is absent or "*", which only asks
+// for the student to exist, as a missing one is a 404 anyway. Weak tags
+// never match, per RFC 9110's strong comparison for If-Match.
+func parseIfMatch(header string) (version uint64, err error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, errInvalidIfMatch
+	}
+	if strings.HasPrefix(header, "W/") {
+		// A weak tag can never match, so ask for a version that cannot exist.
+		return ^uint64(0), nil
+	}
+	version, err = strconv.ParseUint(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version == 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// noneMatch reports whether an If-None-Match header lists etag, using weak
+// comparison as RFC 9110 requires for GET.
+func noneMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch parses the If-Match header of a write, responding with 428
+// when the handler requires one and it is missing, or 400 when it is
+// malformed.
+func (h *StudentHandler) checkIfMatch(w http.ResponseWriter, r *http.Request) (version uint64, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" && h.requireIfMatch {
+		writeProblem(w, r, http.StatusPreconditionRequired, "If-Match header is required; send the ETag from a previous GET")
+		return 0, false
+	}
+	version, err := parseIfMatch(header)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+	return version, true
+}
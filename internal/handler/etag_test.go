@@ -0,0 +1,79 @@
+package handler
+
+import "testing"
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   uint64
+	}{
+		{"", 0},
+		{"*", 0},
+		{" * ", 0},
+		{`"1"`, 1},
+		{` "42" `, 42},
+		{`"18446744073709551615"`, 18446744073709551615},
+		{`W/"3"`, ^uint64(0)},
+	}
+	for _, tt := range tests {
+		got, err := parseIfMatch(tt.header)
+		if err != nil {
+			t.Errorf("parseIfMatch(%q): %v", tt.header, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseIfMatch(%q) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParseIfMatchInvalid(t *testing.T) {
+	for _, header := range []string{
+		`3`,
+		`"3`,
+		`3"`,
+		`"0"`,
+		`""`,
+		`"-1"`,
+		`"abc"`,
+		`"1", "2"`,
+		`*, "1"`,
+		`"18446744073709551616"`,
+	} {
+		if _, err := parseIfMatch(header); err != errInvalidIfMatch {
+			t.Errorf("parseIfMatch(%q) error = %v, want errInvalidIfMatch", header, err)
+		}
+	}
+}
+
+func TestStudentETagRoundTrip(t *testing.T) {
+	for _, version := range []uint64{1, 7, 1 << 40} {
+		got, err := parseIfMatch(studentETag(version))
+		if err != nil || got != version {
+			t.Errorf("parseIfMatch(studentETag(%d)) = %d, %v", version, got, err)
+		}
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	etag := studentETag(5)
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"5"`, true},
+		{`W/"5"`, true},
+		{`"4", "5"`, true},
+		{`"4",W/"5"`, true},
+		{`*`, true},
+		{`"4"`, false},
+		{`"50"`, false},
+		{`5`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := noneMatch(tt.header, etag); got != tt.want {
+			t.Errorf("noneMatch(%q, %s) = %v, want %v", tt.header, etag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"student-api/internal/workerpool"
+	"sync/atomic"
+)
+
+// DatabaseCheck pings the MySQL connection pool.
+func DatabaseCheck(db *sql.DB) HealthCheck {
+	return HealthCheck{
+		Name: "database",
+		Check: func(ctx context.Context) error {
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+type pendingMigrations interface {
+	Pending(ctx context.Context) (int, error)
+}
+
+// MigrationCheck fails while schema migrations are pending. Once the schema
+// is up to date the result is cached, since it can only regress through a
+// new deployment.
+func MigrationCheck(migrator pendingMigrations) HealthCheck {
+	var upToDate atomic.Bool
+	return HealthCheck{
+		Name: "migrations",
+		Check: func(ctx context.Context) error {
+			if upToDate.Load() {
+				return nil
+			}
+			pending, err := migrator.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if pending > 0 {
+				return fmt.Errorf("%d migration(s) pending", pending)
+			}
+			upToDate.Store(true)
+			return nil
+		},
+	}
+}
+
+// WorkerPoolCheck fails when the job queue is at least maxQueueUsage full,
+// so saturated instances are taken out of rotation until they catch up.
+func WorkerPoolCheck(pool *workerpool.Pool, maxQueueUsage float64) HealthCheck {
+	return HealthCheck{
+		Name: "workerPool",
+		Check: func(ctx context.Context) error {
+			stats := pool.Stats()
+			if stats.QueueCapacity == 0 {
+				if stats.BusyWorkers >= stats.Workers {
+					return fmt.Errorf("all %d workers busy", stats.Workers)
+				}
+				return nil
+			}
+			usage := float64(stats.QueueLength) / float64(stats.QueueCapacity)
+			if usage >= maxQueueUsage {
+				return fmt.Errorf("job queue %d/%d full", stats.QueueLength, stats.QueueCapacity)
+			}
+			return nil
+		},
+	}
+}
@@ -1,27 +1,126 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"student-api/internal/logging"
+	"student-api/internal/version"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-type HealthHandler struct{}
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck is a named readiness dependency check.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	ready  atomic.Bool
+	checks []HealthCheck
+}
 
 type HealthResponse struct {
-	Status  string `json:"status"`
-	Version string `json:"version"`
+	Status     string                     `json:"status"`
+	Version    string                     `json:"version"`
+	Commit     string                     `json:"commit,omitempty"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
 }
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+// ComponentHealth is public, so failures are only logged in detail; errors
+// can name hosts and schema objects.
+type ComponentHealth struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
 }
 
-func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response := HealthResponse{
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	h := &HealthHandler{checks: checks}
+	h.ready.Store(true)
+	return h
+}
+
+// SetReady controls whether the instance reports itself ready. It is
+// cleared during shutdown so load balancers stop routing new traffic here.
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Live reports that the process is up and serving HTTP. It never checks
+// dependencies, so a database outage does not get containers restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	info := version.Get()
+	writeHealth(w, http.StatusOK, HealthResponse{
 		Status:  "ok",
-		Version: "1.0.0",
+		Version: info.Version,
+		Commit:  info.Commit,
+	})
+}
+
+// Ready runs every dependency check concurrently and reports 503 if any
+// fails or the server is shutting down.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	info := version.Get()
+	response := HealthResponse{
+		Status:     "ok",
+		Version:    info.Version,
+		Commit:     info.Commit,
+		Components: make(map[string]ComponentHealth, len(h.checks)),
+	}
+
+	logger := logging.FromContext(r.Context())
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Check(ctx)
+			component := ComponentHealth{
+				Status:    "up",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				component.Status = "down"
+				logger.Warn("health check failed", "check", check.Name, "error", err)
+			}
+			mu.Lock()
+			response.Components[check.Name] = component
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for _, component := range response.Components {
+		if component.Status != "up" {
+			response.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
 	}
+	if !h.ready.Load() {
+		response.Status = "shutting_down"
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, response)
+}
+
+// HealthCheck is kept for the legacy /health route and reports readiness.
+func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	h.Ready(w, r)
+}
 
+func writeHealth(w http.ResponseWriter, status int, response HealthResponse) {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
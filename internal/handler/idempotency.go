@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"student-api/internal/idempotency"
+	"student-api/internal/logging"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// replayedHeaders are the response headers stored with an idempotent
+// response and sent again on replay.
+var replayedHeaders = []string{"Content-Type", "Content-Disposition", "Location", "ETag", "Accept-Patch"}
+
+// maxIdempotentBodyBytes bounds the body buffered to fingerprint a keyed
+// request; it fits the largest batch.
+const maxIdempotentBodyBytes = 16 << 20
+
+// idempotencyStoreTimeout bounds the bookkeeping done after the handler,
+// which must not depend on the request context that may be cancelled.
+const idempotencyStoreTimeout = 5 * time.Second
+
+// Idempotency makes mutating requests carrying an Idempotency-Key safe to
+// retry. The first response for a key is stored and replayed for later
+// requests with the same key and payload; a concurrent duplicate gets 409
+// and a different payload under the same key gets 422. Server errors are
+// not stored, so the client can retry them, and neither are responses
+// marked Cache-Control: no-store, such as a newly created API key.
+//
+// Requests to streamingRoutes, named as the router names them, are passed
+// through without buffering their body, so uploads are not held in memory;
+// they must be safe to repeat on their own.
+func Idempotency(store idempotency.Store, streamingRoutes ...string) func(http.Handler) http.Handler {
+	streaming := make(map[string]bool, len(streamingRoutes))
+	for _, route := range streamingRoutes {
+		streaming[route] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientKey := r.Header.Get(idempotency.Header)
+			if clientKey == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if current := mux.CurrentRoute(r); current != nil && streaming[current.GetName()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			logger := logging.Operation(r.Context(), "Idempotency")
+			if len(clientKey) > idempotency.MaxKeyLength {
+				writeProblem(w, r, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", idempotency.Header, idempotency.MaxKeyLength))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeProblem(w, r, http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("Request body must be at most %d bytes", maxIdempotentBodyBytes))
+				return
+			}
+			if err != nil {
+				writeProblem(w, r, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Keys are scoped to the caller so one client cannot replay
+			// another's response.
+			var subject string
+			if principal := logging.GetPrincipalFromContext(r.Context()); principal != nil {
+				subject = principal.Subject
+			}
+			key := idempotency.Hash([]byte(subject), []byte(r.Method), []byte(r.URL.Path), []byte(clientKey))
+			fingerprint := idempotency.Hash([]byte(r.URL.RawQuery), []byte(r.Header.Get("Content-Type")), body)
+
+			stored, err := store.Acquire(r.Context(), key, fingerprint)
+			switch {
+			case errors.Is(err, idempotency.ErrInProgress):
+				logger.Info("concurrent request with the same key")
+				writeProblem(w, r, http.StatusConflict, err.Error())
+				return
+			case errors.Is(err, idempotency.ErrFingerprintMismatch):
+				logger.Info("key reused with a different payload")
+				writeProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+				return
+			case err != nil:
+				logError(r.Context(), logger, err)
+				writeError(w, r, err)
+				return
+			case stored != nil:
+				logger.Info("replaying stored response", "status", stored.Status)
+				for _, name := range replayedHeaders {
+					if v := stored.Header.Get(name); v != "" {
+						w.Header().Set(name, v)
+					}
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+
+			rec := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				recovered := recover()
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreTimeout)
+				defer cancel()
+				// Nothing is stored if the handler wrote no response (client
+				// gone), panicked, failed in a way worth retrying, or
+				// returned a secret that must not be kept.
+				if recovered != nil || !rec.wroteHeader || noStore(w.Header()) ||
+					rec.status >= http.StatusInternalServerError || rec.status == http.StatusTooManyRequests {
+					err = store.Release(ctx, key)
+				} else {
+					resp := &idempotency.Response{Status: rec.status, Header: http.Header{}, Body: rec.body.Bytes()}
+					for _, name := range replayedHeaders {
+						if v := w.Header().Get(name); v != "" {
+							resp.Header.Set(name, v)
+						}
+					}
+					err = store.Complete(ctx, key, resp)
+				}
+				if err != nil {
+					logger.Error("failed to record response", "error", err)
+				}
+				if recovered != nil {
+					panic(recovered)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func noStore(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseCapture passes a response through while keeping a copy of its
+// status and body.
+type responseCapture struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(code int) {
+	if !c.wroteHeader {
+		c.status = code
+		c.wroteHeader = true
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.wroteHeader = true
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
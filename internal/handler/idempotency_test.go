@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"student-api/internal/idempotency"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// memoryIdempotencyStore keeps claims and responses in a map and ignores
+// fingerprints.
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	claimed   map[string]bool
+	responses map[string]*idempotency.Response
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{claimed: map[string]bool{}, responses: map[string]*idempotency.Response{}}
+}
+
+func (s *memoryIdempotencyStore) Acquire(ctx context.Context, key, fingerprint string) (*idempotency.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if resp := s.responses[key]; resp != nil {
+		return resp, nil
+	}
+	if s.claimed[key] {
+		return nil, idempotency.ErrInProgress
+	}
+	s.claimed[key] = true
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, resp *idempotency.Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[key] = resp
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claimed, key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Purge(ctx context.Context) (int64, error) { return 0, nil }
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	h := Idempotency(newMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/students", strings.NewReader(`{}`))
+		req.Header.Set(idempotency.Header, "k1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated || rec.Body.String() != `{"id":1}` {
+			t.Errorf("request %d: %d %s", i, rec.Code, rec.Body)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyDoesNotStoreNoStoreResponses(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	calls := 0
+	h := Idempotency(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token":"secret"}`))
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", strings.NewReader(`{}`))
+		req.Header.Set(idempotency.Header, "k1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Errorf("request %d: status %d", i, rec.Code)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+	if len(store.responses) != 0 {
+		t.Errorf("stored %d responses, want none", len(store.responses))
+	}
+}
+
+func TestIdempotencySkipsStreamingRoutes(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var bodies []string
+	router := mux.NewRouter()
+	router.Use(Idempotency(store, "ImportStudents"))
+	router.HandleFunc("/api/students:import", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST").Name("ImportStudents")
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/students:import", strings.NewReader("email\nann@example.com\n"))
+		req.Header.Set(idempotency.Header, "k1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(bodies) != 2 || bodies[1] != "email\nann@example.com\n" {
+		t.Errorf("import handler read %q, want the body twice", bodies)
+	}
+	if len(store.claimed) != 0 {
+		t.Errorf("claimed %d keys, want none", len(store.claimed))
+	}
+}
+
+func TestIdempotencyRejectsLargeBodies(t *testing.T) {
+	h := Idempotency(newMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler ran for an oversized body")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/students:batch", bytes.NewReader(make([]byte, maxIdempotentBodyBytes+1)))
+	req.Header.Set(idempotency.Header, "k1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", rec.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	TraceID  string              `json:"traceId,omitempty"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+}
+
+// problemTypes gives statuses with API-specific semantics a stable type URI.
+// Other statuses use "about:blank" as RFC 7807 recommends.
+var problemTypes = map[int]string{
+	http.StatusBadRequest:           "/problems/bad-request",
+	http.StatusUnauthorized:         "/problems/unauthorized",
+	http.StatusForbidden:            "/problems/forbidden",
+	http.StatusNotFound:             "/problems/not-found",
+	http.StatusMethodNotAllowed:     "/problems/method-not-allowed",
+	http.StatusConflict:             "/problems/conflict",
+	http.StatusPreconditionFailed:   "/problems/precondition-failed",
+	http.StatusUnsupportedMediaType: "/problems/unsupported-media-type",
+	http.StatusPreconditionRequired: "/problems/precondition-required",
+	http.StatusUnprocessableEntity:  "/problems/validation-error",
+	http.StatusFailedDependency:     "/problems/batch-aborted",
+	http.StatusTooManyRequests:      "/problems/rate-limited",
+	http.StatusServiceUnavailable:   "/problems/service-unavailable",
+	http.StatusGatewayTimeout:       "/problems/timeout",
+}
+
+func newProblem(r *http.Request, status int, detail string) *Problem {
+	return statusProblem(status, detail).forRequest(r)
+}
+
+// statusProblem builds a problem for status without request details.
+func statusProblem(status int, detail string) *Problem {
+	problemType, ok := problemTypes[status]
+	if !ok {
+		problemType = "about:blank"
+	}
+	return &Problem{
+		Type:   problemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// forRequest fills in the request URI and trace id.
+func (p *Problem) forRequest(r *http.Request) *Problem {
+	p.Instance = r.URL.RequestURI()
+	if traceID := logging.GetTraceIDFromContext(r.Context()); traceID != "unknown" {
+		p.TraceID = traceID
+	}
+	return p
+}
+
+// writeProblem sends a problem+json response with the given status and detail.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	newProblem(r, status, detail).Write(w)
+}
+
+func (p *Problem) Write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// NotFoundHandler answers requests that match no route.
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusNotFound, "No resource matches this path")
+	})
+}
+
+// MethodNotAllowedHandler answers requests whose path matches a route but
+// whose method does not.
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusMethodNotAllowed, r.Method+" is not supported for this resource")
+	})
+}
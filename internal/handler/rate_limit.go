@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"student-api/internal/logging"
+	"student-api/internal/ratelimit"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimit meters requests whose path starts with pathPrefix against the
+// limit of the matched route, named as in limits. Each client has its own
+// buckets: an API key, else the authenticated subject, else the client IP.
+// Responses carry RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers; requests over the limit get 429 with
+// Retry-After. If the store fails the request is let through.
+func RateLimit(store ratelimit.Store, limits ratelimit.Limits, pathPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var route string
+			if current := mux.CurrentRoute(r); current != nil {
+				route = current.GetName()
+			}
+			bucket, limit, ok := limits.For(route)
+			if !ok || !strings.HasPrefix(r.URL.Path, pathPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			logger := logging.Operation(r.Context(), "RateLimit")
+
+			client := rateLimitClient(r)
+			result, err := store.Take(r.Context(), bucket+"|"+client, limit, time.Now())
+			if err != nil {
+				logError(r.Context(), logger, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Period)))
+			if !result.Allowed {
+				retryAfter := max(ceilSeconds(result.RetryAfter), 1)
+				logger.Info("rate limit exceeded", "bucket", bucket, "limit", limit.String())
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				writeProblem(w, r, http.StatusTooManyRequests,
+					fmt.Sprintf("Rate limit of %v exceeded; try again in %ds", limit, retryAfter))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitClient identifies the caller the limit applies to.
+func rateLimitClient(r *http.Request) string {
+	if principal := logging.GetPrincipalFromContext(r.Context()); principal != nil {
+		if principal.KeyID != "" {
+			return "key:" + principal.KeyID
+		}
+		return "sub:" + principal.Subject
+	}
+	return "ip:" + logging.ClientIP(r)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
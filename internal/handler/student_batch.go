@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+)
+
+const maxBatchBodyBytes = 16 << 20
+
+// BatchRequest is the body of POST /api/students:batch.
+type BatchRequest struct {
+	// Mode is "atomic" (default) or "best-effort".
+	Mode       domain.BatchMode        `json:"mode"`
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+type BatchOperationRequest struct {
+	Op      domain.BatchOp  `json:"op"`
+	ID      uint            `json:"id,omitempty"`
+	Version uint64          `json:"version,omitempty"`
+	Student json.RawMessage `json:"student,omitempty"`
+}
+
+// BatchResponse lists one result per operation, in request order, with the
+// HTTP status the operation would have received on its own.
+type BatchResponse struct {
+	Mode    domain.BatchMode  `json:"mode"`
+	Results []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	Index   int             `json:"index"`
+	Op      domain.BatchOp  `json:"op"`
+	Status  int             `json:"status"`
+	ID      uint            `json:"id,omitempty"`
+	ETag    string          `json:"etag,omitempty"`
+	Student *domain.Student `json:"student,omitempty"`
+	Error   *Problem        `json:"error,omitempty"`
+}
+
+// BatchStudents executes a list of create, update and delete operations as
+// one job, answering 207 Multi-Status with per-item results.
+func (h *StudentHandler) BatchStudents(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "BatchStudents")
+
+	req, err := decodeBatchRequest(w, r)
+	if err != nil {
+		logger.Info("invalid request body", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if n := len(req.Operations); n == 0 || n > domain.MaxBatchOperations {
+		logger.Info("invalid batch size", "operations", n)
+		writeProblem(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("operations must contain between 1 and %d entries", domain.MaxBatchOperations))
+		return
+	}
+
+	ops := make([]domain.BatchOperation, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = newBatchOperation(op)
+	}
+
+	logger.Debug("executing batch", "operations", len(ops), "mode", req.Mode)
+
+	data, ok := h.runJob(w, r, "BatchStudents", func(ctx context.Context) (interface{}, error) {
+		return h.service.ExecuteBatch(ctx, req.Mode, ops)
+	})
+	if !ok {
+		return
+	}
+
+	results, ok := data.([]domain.BatchResult)
+	if !ok {
+		logger.Error("unexpected response data type")
+		writeProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := BatchResponse{Mode: req.Mode, Results: make([]BatchItemResult, len(results))}
+	failed := 0
+	for i, result := range results {
+		resp.Results[i] = newBatchItemResult(i, result)
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	logger.Info("batch finished", "succeeded", len(results)-failed, "failed", failed)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMultiStatus)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func decodeBatchRequest(w http.ResponseWriter, r *http.Request) (*BatchRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	var req BatchRequest
+	if err := dec.Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrMalformedJSON, err)
+	}
+
+	switch req.Mode {
+	case "":
+		req.Mode = domain.BatchAtomic
+	case domain.BatchAtomic, domain.BatchBestEffort:
+	default:
+		return nil, fmt.Errorf("mode must be %q or %q", domain.BatchAtomic, domain.BatchBestEffort)
+	}
+	return &req, nil
+}
+
+// newBatchOperation decodes one request entry. Problems with the entry are
+// recorded on the operation so they are reported in its result.
+func newBatchOperation(req BatchOperationRequest) domain.BatchOperation {
+	op := domain.BatchOperation{Op: req.Op, ID: req.ID, Version: req.Version}
+
+	switch req.Op {
+	case domain.BatchCreate, domain.BatchUpdate, domain.BatchDelete:
+	default:
+		op.Err = domain.NewValidationError(nil, "op must be one of create, update, delete")
+		return op
+	}
+	if req.Op != domain.BatchCreate && req.ID == 0 {
+		op.Err = domain.NewValidationError(nil, "%s requires an id", req.Op)
+		return op
+	}
+	if req.Op == domain.BatchDelete {
+		return op
+	}
+
+	if len(req.Student) == 0 {
+		op.Err = domain.NewValidationError(nil, "%s requires a student", req.Op)
+		return op
+	}
+	student, err := domain.ParseStudentJSON(req.Student)
+	if err != nil {
+		if domain.ErrorMessage(err) == "" {
+			err = domain.NewValidationError(err, "student must be a JSON object")
+		}
+		op.Err = err
+		return op
+	}
+	op.Student = student
+	return op
+}
+
+var batchSuccessStatus = map[domain.BatchOp]int{
+	domain.BatchCreate: http.StatusCreated,
+	domain.BatchUpdate: http.StatusOK,
+	domain.BatchDelete: http.StatusNoContent,
+}
+
+func newBatchItemResult(index int, result domain.BatchResult) BatchItemResult {
+	item := BatchItemResult{Index: index, Op: result.Op, ID: result.ID}
+	if result.Err != nil {
+		item.Error = errorProblem(result.Err)
+		item.Status = item.Error.Status
+		return item
+	}
+	item.Status = batchSuccessStatus[result.Op]
+	if result.Student != nil {
+		item.Student = result.Student
+		item.ETag = studentETag(result.Student.Version)
+	}
+	return item
+}
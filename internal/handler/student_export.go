@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"student-api/internal/domain"
+	"student-api/internal/importer"
+	"student-api/internal/logging"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportFlushRows is how many rows are written between flushes.
+const exportFlushRows = 1000
+
+var exportHeader = []string{"id", "firstName", "lastName", "email", "age", "grade", "createdAt", "updatedAt"}
+
+// studentExporter writes students in one export format.
+type studentExporter interface {
+	contentType() string
+	// begin is called once before the first student.
+	begin(w io.Writer) error
+	write(student *domain.Student) error
+	// flush sends the rows written so far to the client, where the format
+	// allows it.
+	flush(rc *http.ResponseController) error
+	// end completes the document.
+	end() error
+	// close releases resources; it is called even if end is not.
+	close()
+}
+
+// ExportStudents streams every student matching the list filters as CSV,
+// NDJSON or XLSX. It runs on the request goroutine rather than the worker
+// pool so that long exports are bounded only by the client connection, not
+// by the job timeouts.
+func (h *StudentHandler) ExportStudents(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "ExportStudents")
+
+	query, err := parseStudentQuery(r)
+	if err != nil {
+		logger.Info("invalid query", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	var exporter studentExporter
+	switch format {
+	case "csv":
+		exporter = &csvExporter{}
+	case "ndjson":
+		exporter = &ndjsonExporter{}
+	case "xlsx":
+		exporter = &xlsxExporter{}
+	default:
+		logger.Info("invalid format", "format", format)
+		writeProblem(w, r, http.StatusBadRequest, "format must be csv, ndjson or xlsx")
+		return
+	}
+
+	logger.Debug("exporting students", "format", format)
+
+	defer exporter.close()
+
+	rc := http.NewResponseController(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", exporter.contentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="students.%s"`, format))
+		// Stop nginx from buffering the whole export before relaying it.
+		w.Header().Set("X-Accel-Buffering", "no")
+		return exporter.begin(w)
+	}
+
+	count := 0
+	err = h.service.ExportStudents(r.Context(), query, func(student *domain.Student) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := exporter.write(student); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushRows == 0 {
+			return exporter.flush(rc)
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = exporter.end()
+	}
+
+	switch {
+	case err == nil:
+		logger.Info("students exported", "count", count)
+	case !started:
+		logError(r.Context(), logger, err)
+		writeError(w, r, err)
+	default:
+		// Part of the body is already sent; abort the connection so the
+		// client sees a failed download rather than a truncated file.
+		logger.Error("export aborted", "count", count, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) contentType() string { return importer.CSVContentType }
+
+func (e *csvExporter) begin(w io.Writer) error {
+	e.w = csv.NewWriter(w)
+	return e.w.Write(exportHeader)
+}
+
+func (e *csvExporter) write(s *domain.Student) error {
+	return e.w.Write([]string{
+		strconv.FormatUint(uint64(s.ID), 10),
+		importer.EscapeFormula(s.FirstName),
+		importer.EscapeFormula(s.LastName),
+		importer.EscapeFormula(s.Email),
+		strconv.Itoa(s.Age),
+		strconv.FormatFloat(s.Grade, 'f', -1, 64),
+		formatTime(s.CreatedAt),
+		formatTime(s.UpdatedAt),
+	})
+}
+
+func (e *csvExporter) flush(rc *http.ResponseController) error {
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+func (e *csvExporter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) close() {}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExporter) contentType() string { return "application/x-ndjson" }
+
+func (e *ndjsonExporter) begin(w io.Writer) error {
+	e.enc = json.NewEncoder(w)
+	return nil
+}
+
+func (e *ndjsonExporter) write(s *domain.Student) error { return e.enc.Encode(s) }
+
+func (e *ndjsonExporter) flush(rc *http.ResponseController) error { return rc.Flush() }
+
+func (e *ndjsonExporter) end() error { return nil }
+
+func (e *ndjsonExporter) close() {}
+
+// xlsxExporter uses excelize's stream writer, which spills rows to a
+// temporary file instead of holding them in memory. The workbook is a zip
+// archive, so it is only sent once the last row is written.
+type xlsxExporter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (e *xlsxExporter) contentType() string { return importer.XLSXContentType }
+
+func (e *xlsxExporter) begin(w io.Writer) error {
+	e.out = w
+	e.file = excelize.NewFile()
+	sheet := e.file.GetSheetName(0)
+	stream, err := e.file.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	e.stream = stream
+	header := make([]interface{}, len(exportHeader))
+	for i, name := range exportHeader {
+		header[i] = name
+	}
+	return e.writeRow(header)
+}
+
+func (e *xlsxExporter) write(s *domain.Student) error {
+	return e.writeRow([]interface{}{
+		s.ID, importer.EscapeFormula(s.FirstName), importer.EscapeFormula(s.LastName),
+		importer.EscapeFormula(s.Email), s.Age, s.Grade,
+		formatTime(s.CreatedAt), formatTime(s.UpdatedAt),
+	})
+}
+
+func (e *xlsxExporter) writeRow(values []interface{}) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.stream.SetRow(cell, values)
+}
+
+func (e *xlsxExporter) flush(*http.ResponseController) error { return nil }
+
+func (e *xlsxExporter) end() error {
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := e.file.WriteTo(e.out)
+	return err
+}
+
+func (e *xlsxExporter) close() {
+	if e.file != nil {
+		e.file.Close()
+	}
+}
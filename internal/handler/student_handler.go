@@ -3,271 +3,381 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"student-api/internal/domain"
+	"student-api/internal/importer"
 	"student-api/internal/logging"
-	"sync"
+	"student-api/internal/tracing"
+	"student-api/internal/workerpool"
 	"time"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("handler")
+
 type ResponseChannel struct {
 	Data  interface{}
 	Error error
 }
 
-type StudentHandler struct {
-	service domain.StudentService
-	logger  *logging.RequestLogger
-	workers int
-	jobs    chan func()
-	wg      sync.WaitGroup
+// Timeouts bounds how long a request may wait for its job and how long the
+// job itself may spend in the service and repository layers.
+type Timeouts struct {
+	Request   time.Duration
+	Operation time.Duration
+	// PerOperation overrides Operation for individual handler operations,
+	// keyed by name (e.g. "GetAllStudents").
+	PerOperation map[string]time.Duration
+	// Import bounds a whole import, which runs outside the worker pool.
+	Import time.Duration
 }
 
-func NewStudentHandler(service domain.StudentService, logger *logging.RequestLogger) *StudentHandler {
-	h := &StudentHandler{
-		service: service,
-		logger:  logger,
-		workers: 50, // Number of worker goroutines
-		jobs:    make(chan func(), 100), // Buffer size of 100 jobs
+func (t Timeouts) forOperation(operation string) time.Duration {
+	if d, ok := t.PerOperation[operation]; ok {
+		return d
 	}
+	return t.Operation
+}
 
-	// Start worker pool
-	for i := 0; i < h.workers; i++ {
-		h.wg.Add(1)
-		go h.startWorker()
-	}
+// Config holds the StudentHandler settings that come from configuration.
+type Config struct {
+	Timeouts Timeouts
+	// RequireIfMatch makes If-Match mandatory on PUT and PATCH.
+	RequireIfMatch bool
+	// ImportMapping resolves import file headers; requests can extend it
+	// with the mapping query parameter.
+	ImportMapping importer.Mapping
+}
 
-	return h
+type StudentHandler struct {
+	service        domain.StudentService
+	pool           *workerpool.Pool
+	timeouts       Timeouts
+	requireIfMatch bool
+	importMapping  importer.Mapping
 }
 
-func (h *StudentHandler) startWorker() {
-	defer h.wg.Done()
-	for job := range h.jobs {
-		job()
+func NewStudentHandler(service domain.StudentService, pool *workerpool.Pool, cfg Config) *StudentHandler {
+	return &StudentHandler{
+		service:        service,
+		pool:           pool,
+		timeouts:       cfg.Timeouts,
+		requireIfMatch: cfg.RequireIfMatch,
+		importMapping:  cfg.ImportMapping,
 	}
 }
 
-func (h *StudentHandler) scheduleJob(job func()) {
-	h.jobs <- job
+// scheduleJob hands job to the worker pool, translating saturation and
+// shutdown into errors the client is told to retry.
+func (h *StudentHandler) scheduleJob(job func()) error {
+	switch err := h.pool.Submit(job); {
+	case errors.Is(err, workerpool.ErrQueueFull):
+		return domain.NewUnavailableError(err, "server is busy, retry later")
+	case errors.Is(err, workerpool.ErrClosed):
+		return domain.NewUnavailableError(err, "server is shutting down")
+	default:
+		return err
+	}
 }
 
-func (h *StudentHandler) CreateStudent(w http.ResponseWriter, r *http.Request) {
-	traceID := logging.GetTraceIDFromContext(r.Context())
+// runJob executes fn on the worker pool and waits for its result. The job
+// context derives from the request, so a client disconnect or the request
+// timeout cancels the database work. On failure the error response has
+// already been written and ok is false.
+func (h *StudentHandler) runJob(w http.ResponseWriter, r *http.Request, operation string, fn func(ctx context.Context) (interface{}, error)) (data interface{}, ok bool) {
+	logger := logging.Operation(r.Context(), operation)
 	respChan := make(chan ResponseChannel, 1)
-	
-	var student domain.Student
-	if err := json.NewDecoder(r.Body).Decode(&student); err != nil {
-		h.logger.LogOperation(traceID, "CreateStudent", fmt.Sprintf("Invalid request body: %v", err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
 
-	h.logger.LogOperation(traceID, "CreateStudent", fmt.Sprintf("Creating student: %s %s", student.FirstName, student.LastName))
-	
-	// Process asynchronously
-	h.scheduleJob(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeouts.Request)
+	defer cancel()
+
+	// Process asynchronously; the job span starts when the job is queued
+	// so that it shows the wait for a worker
+	enqueued := time.Now()
+	err := h.scheduleJob(func() {
+		jobCtx, span := tracer.Start(ctx, "job "+operation,
+			trace.WithTimestamp(enqueued),
+			trace.WithAttributes(attribute.String("job.operation", operation)),
+		)
+		span.SetAttributes(attribute.Float64("job.queue_wait_ms", float64(time.Since(enqueued).Microseconds())/1000))
+		span.AddEvent("job started")
+		// Answer the request if fn panics, then let the pool log the panic
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err := fmt.Errorf("%s panicked: %v", operation, recovered)
+				tracing.End(span, err)
+				respChan <- ResponseChannel{Error: err}
+				panic(recovered)
+			}
+		}()
+
+		if err := jobCtx.Err(); err != nil {
+			// The client gave up while the job was queued.
+			tracing.End(span, err)
+			respChan <- ResponseChannel{Error: err}
+			return
+		}
+		opCtx, cancel := context.WithTimeout(jobCtx, h.timeouts.forOperation(operation))
 		defer cancel()
 
-		err := h.service.CreateStudent(ctx, &student)
-		respChan <- ResponseChannel{Data: student, Error: err}
+		data, err := fn(opCtx)
+		tracing.End(span, err)
+		respChan <- ResponseChannel{Data: data, Error: err}
 	})
+	if err != nil {
+		logger.Warn("job rejected", "error", err)
+		writeError(w, r, err)
+		return nil, false
+	}
 
 	// Wait for response with timeout
 	select {
 	case resp := <-respChan:
 		if resp.Error != nil {
-			h.logger.LogOperation(traceID, "CreateStudent", fmt.Sprintf("Error creating student: %v", resp.Error))
-			http.Error(w, resp.Error.Error(), http.StatusInternalServerError)
-			return
+			logError(r.Context(), logger, resp.Error)
+			if errors.Is(resp.Error, context.DeadlineExceeded) {
+				writeProblem(w, r, http.StatusGatewayTimeout, "Request timeout")
+				return nil, false
+			}
+			writeError(w, r, resp.Error)
+			return nil, false
 		}
-		h.logger.LogOperation(traceID, "CreateStudent", fmt.Sprintf("Student created successfully with ID: %d", student.ID))
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(resp.Data)
-	case <-time.After(15 * time.Second):
-		h.logger.LogOperation(traceID, "CreateStudent", "Operation timed out")
-		http.Error(w, "Request timeout", http.StatusGatewayTimeout)
+		return resp.Data, true
+	case <-ctx.Done():
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			logger.Info("client disconnected")
+			return nil, false
+		}
+		logger.Warn("operation timed out")
+		writeProblem(w, r, http.StatusGatewayTimeout, "Request timeout")
+		return nil, false
 	}
 }
 
-func (h *StudentHandler) GetStudent(w http.ResponseWriter, r *http.Request) {
-	traceID := logging.GetTraceIDFromContext(r.Context())
-	respChan := make(chan ResponseChannel, 1)
-	
-	vars := mux.Vars(r)
-	id, err := strconv.ParseUint(vars["id"], 10, 32)
+func (h *StudentHandler) CreateStudent(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "CreateStudent")
+
+	student, err := decodeStudent(w, r)
 	if err != nil {
-		h.logger.LogOperation(traceID, "GetStudent", fmt.Sprintf("Invalid student ID: %s", vars["id"]))
-		http.Error(w, "Invalid student ID", http.StatusBadRequest)
+		logger.Info("invalid request body", "error", err)
+		writeDecodeError(w, r, err)
 		return
 	}
 
-	h.logger.LogOperation(traceID, "GetStudent", fmt.Sprintf("Fetching student with ID: %d", id))
-	
-	// Process asynchronously
-	h.scheduleJob(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	logger.Debug("creating student", "first_name", student.FirstName, "last_name", student.LastName)
 
-		student, err := h.service.GetStudent(ctx, uint(id))
-		respChan <- ResponseChannel{Data: student, Error: err}
+	data, ok := h.runJob(w, r, "CreateStudent", func(ctx context.Context) (interface{}, error) {
+		err := h.service.CreateStudent(ctx, student)
+		return student, err
 	})
+	if !ok {
+		return
+	}
 
-	// Wait for response with timeout
-	select {
-	case resp := <-respChan:
-		if resp.Error != nil {
-			h.logger.LogOperation(traceID, "GetStudent", fmt.Sprintf("Error fetching student: %v", resp.Error))
-			http.Error(w, resp.Error.Error(), http.StatusInternalServerError)
+	logger.Info("student created", "student_id", student.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", studentETag(student.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *StudentHandler) GetStudent(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "GetStudent")
+
+	id, ok := h.studentID(w, r, "GetStudent")
+	if !ok {
+		return
+	}
+
+	includeDeleted, err := parseBoolParam(r.URL.Query(), "includeDeleted")
+	if err != nil {
+		logger.Info("invalid query", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	var asOf time.Time
+	if raw := r.URL.Query().Get("asOf"); raw != "" {
+		if asOf, err = time.Parse(time.RFC3339, raw); err != nil {
+			logger.Info("invalid asOf", "asOf", raw)
+			writeProblem(w, r, http.StatusBadRequest, "asOf must be an RFC 3339 timestamp")
 			return
 		}
+	}
 
-		student, ok := resp.Data.(*domain.Student)
-		if !ok || student == nil {
-			h.logger.LogOperation(traceID, "GetStudent", fmt.Sprintf("Student not found with ID: %d", id))
-			http.Error(w, "Student not found", http.StatusNotFound)
-			return
+	logger.Debug("fetching student", "student_id", id)
+
+	data, ok := h.runJob(w, r, "GetStudent", func(ctx context.Context) (interface{}, error) {
+		if !asOf.IsZero() {
+			return h.service.GetStudentAsOf(ctx, id, asOf, includeDeleted)
 		}
+		return h.service.GetStudent(ctx, id, includeDeleted)
+	})
+	if !ok {
+		return
+	}
 
-		h.logger.LogOperation(traceID, "GetStudent", fmt.Sprintf("Successfully fetched student: %s %s", student.FirstName, student.LastName))
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(student)
-	case <-time.After(15 * time.Second):
-		h.logger.LogOperation(traceID, "GetStudent", "Operation timed out")
-		http.Error(w, "Request timeout", http.StatusGatewayTimeout)
+	student, ok := data.(*domain.Student)
+	if !ok || student == nil {
+		logger.Info("student not found", "student_id", id)
+		writeProblem(w, r, http.StatusNotFound, "Student not found")
+		return
 	}
+
+	// A past state is not the current representation, so it gets no ETag
+	// that could be sent back in If-Match.
+	if asOf.IsZero() {
+		etag := studentETag(student.Version)
+		w.Header().Set("ETag", etag)
+		if noneMatch(r.Header.Get("If-None-Match"), etag) {
+			logger.Debug("student not modified", "student_id", id)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	logger.Debug("student fetched", "student_id", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(student)
 }
 
 func (h *StudentHandler) GetAllStudents(w http.ResponseWriter, r *http.Request) {
-	traceID := logging.GetTraceIDFromContext(r.Context())
-	respChan := make(chan ResponseChannel, 1)
+	logger := logging.Operation(r.Context(), "GetAllStudents")
 
-	h.logger.LogOperation(traceID, "GetAllStudents", "Fetching all students")
+	query, err := parseStudentQuery(r)
+	if err != nil {
+		logger.Info("invalid query", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Process asynchronously
-	h.scheduleJob(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	logger.Debug("fetching students", "limit", query.Limit, "offset", query.Offset)
 
-		students, err := h.service.GetAllStudents(ctx)
-		respChan <- ResponseChannel{Data: students, Error: err}
+	data, ok := h.runJob(w, r, "GetAllStudents", func(ctx context.Context) (interface{}, error) {
+		return h.service.GetAllStudents(ctx, query)
 	})
+	if !ok {
+		return
+	}
 
-	// Wait for response with timeout
-	select {
-	case resp := <-respChan:
-		if resp.Error != nil {
-			h.logger.LogOperation(traceID, "GetAllStudents", fmt.Sprintf("Error fetching students: %v", resp.Error))
-			http.Error(w, resp.Error.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		students, ok := resp.Data.([]domain.Student)
-		if !ok {
-			h.logger.LogOperation(traceID, "GetAllStudents", "Error converting response data")
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-
-		h.logger.LogOperation(traceID, "GetAllStudents", fmt.Sprintf("Successfully fetched %d students", len(students)))
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(students)
-	case <-time.After(15 * time.Second):
-		h.logger.LogOperation(traceID, "GetAllStudents", "Operation timed out")
-		http.Error(w, "Request timeout", http.StatusGatewayTimeout)
+	page, ok := data.(*domain.StudentPage)
+	if !ok || page == nil {
+		logger.Error("unexpected response data type")
+		writeProblem(w, r, http.StatusInternalServerError, "")
+		return
 	}
+
+	logger.Debug("students fetched", "count", len(page.Students), "total", page.Total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newStudentListResponse(r, query, page))
 }
 
 func (h *StudentHandler) UpdateStudent(w http.ResponseWriter, r *http.Request) {
-	traceID := logging.GetTraceIDFromContext(r.Context())
-	respChan := make(chan ResponseChannel, 1)
+	logger := logging.Operation(r.Context(), "UpdateStudent")
 
-	vars := mux.Vars(r)
-	id, err := strconv.ParseUint(vars["id"], 10, 32)
-	if err != nil {
-		h.logger.LogOperation(traceID, "UpdateStudent", fmt.Sprintf("Invalid student ID: %s", vars["id"]))
-		http.Error(w, "Invalid student ID", http.StatusBadRequest)
+	id, ok := h.studentID(w, r, "UpdateStudent")
+	if !ok {
 		return
 	}
 
-	var student domain.Student
-	if err := json.NewDecoder(r.Body).Decode(&student); err != nil {
-		h.logger.LogOperation(traceID, "UpdateStudent", fmt.Sprintf("Invalid request body: %v", err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	version, ok := h.checkIfMatch(w, r)
+	if !ok {
+		logger.Info("missing or invalid If-Match header")
 		return
 	}
-	student.ID = uint(id)
 
-	h.logger.LogOperation(traceID, "UpdateStudent", fmt.Sprintf("Updating student with ID: %d", id))
+	student, err := decodeStudent(w, r)
+	if err != nil {
+		logger.Info("invalid request body", "error", err)
+		writeDecodeError(w, r, err)
+		return
+	}
+	student.ID = id
+	student.Version = version
 
-	// Process asynchronously
-	h.scheduleJob(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	logger.Debug("updating student", "student_id", id)
 
-		err := h.service.UpdateStudent(ctx, &student)
-		respChan <- ResponseChannel{Data: student, Error: err}
+	data, ok := h.runJob(w, r, "UpdateStudent", func(ctx context.Context) (interface{}, error) {
+		err := h.service.UpdateStudent(ctx, student)
+		return student, err
 	})
-
-	// Wait for response with timeout
-	select {
-	case resp := <-respChan:
-		if resp.Error != nil {
-			h.logger.LogOperation(traceID, "UpdateStudent", fmt.Sprintf("Error updating student: %v", resp.Error))
-			http.Error(w, resp.Error.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		h.logger.LogOperation(traceID, "UpdateStudent", fmt.Sprintf("Successfully updated student: %s %s", student.FirstName, student.LastName))
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp.Data)
-	case <-time.After(15 * time.Second):
-		h.logger.LogOperation(traceID, "UpdateStudent", "Operation timed out")
-		http.Error(w, "Request timeout", http.StatusGatewayTimeout)
+	if !ok {
+		return
 	}
+
+	logger.Info("student updated", "student_id", id, "version", student.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", studentETag(student.Version))
+	json.NewEncoder(w).Encode(data)
 }
 
 func (h *StudentHandler) DeleteStudent(w http.ResponseWriter, r *http.Request) {
-	traceID := logging.GetTraceIDFromContext(r.Context())
-	respChan := make(chan ResponseChannel, 1)
+	logger := logging.Operation(r.Context(), "DeleteStudent")
 
-	vars := mux.Vars(r)
-	id, err := strconv.ParseUint(vars["id"], 10, 32)
-	if err != nil {
-		h.logger.LogOperation(traceID, "DeleteStudent", fmt.Sprintf("Invalid student ID: %s", vars["id"]))
-		http.Error(w, "Invalid student ID", http.StatusBadRequest)
+	id, ok := h.studentID(w, r, "DeleteStudent")
+	if !ok {
 		return
 	}
 
-	h.logger.LogOperation(traceID, "DeleteStudent", fmt.Sprintf("Deleting student with ID: %d", id))
+	logger.Debug("deleting student", "student_id", id)
 
-	// Process asynchronously
-	h.scheduleJob(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	_, ok = h.runJob(w, r, "DeleteStudent", func(ctx context.Context) (interface{}, error) {
+		return nil, h.service.DeleteStudent(ctx, id)
+	})
+	if !ok {
+		return
+	}
+
+	logger.Info("student deleted", "student_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreStudent undoes a soft delete, answering 409 if the student is not
+// deleted or its email has been taken by another student since.
+func (h *StudentHandler) RestoreStudent(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "RestoreStudent")
+
+	id, ok := h.studentID(w, r, "RestoreStudent")
+	if !ok {
+		return
+	}
+
+	logger.Debug("restoring student", "student_id", id)
 
-		err := h.service.DeleteStudent(ctx, uint(id))
-		respChan <- ResponseChannel{Error: err}
+	data, ok := h.runJob(w, r, "RestoreStudent", func(ctx context.Context) (interface{}, error) {
+		return h.service.RestoreStudent(ctx, id)
 	})
+	if !ok {
+		return
+	}
 
-	// Wait for response with timeout
-	select {
-	case resp := <-respChan:
-		if resp.Error != nil {
-			h.logger.LogOperation(traceID, "DeleteStudent", fmt.Sprintf("Error deleting student: %v", resp.Error))
-			http.Error(w, resp.Error.Error(), http.StatusInternalServerError)
-			return
-		}
+	student, ok := data.(*domain.Student)
+	if !ok || student == nil {
+		logger.Error("unexpected response data type")
+		writeProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
 
-		h.logger.LogOperation(traceID, "DeleteStudent", fmt.Sprintf("Successfully deleted student with ID: %d", id))
-		w.WriteHeader(http.StatusNoContent)
-	case <-time.After(15 * time.Second):
-		h.logger.LogOperation(traceID, "DeleteStudent", "Operation timed out")
-		http.Error(w, "Request timeout", http.StatusGatewayTimeout)
+	logger.Info("student restored", "student_id", id)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", studentETag(student.Version))
+	json.NewEncoder(w).Encode(student)
+}
+
+// studentID parses the {id} route variable, writing a 400 if it is invalid.
+func (h *StudentHandler) studentID(w http.ResponseWriter, r *http.Request, operation string) (uint, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, strconv.IntSize)
+	if err != nil {
+		logger := logging.Operation(r.Context(), operation)
+		logger.Info("invalid student ID", "id", vars["id"])
+		writeProblem(w, r, http.StatusBadRequest, "Invalid student ID")
+		return 0, false
 	}
+	return uint(id), true
 }
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"student-api/internal/workerpool"
+	"testing"
+	"time"
+)
+
+func TestRunJobAnswersPanickingJob(t *testing.T) {
+	pool := workerpool.New(workerpool.Config{Workers: 1, QueueSize: 1})
+	defer pool.Shutdown(context.Background())
+	h := NewStudentHandler(nil, pool, Config{Timeouts: Timeouts{Request: 5 * time.Second, Operation: time.Second}})
+
+	rec := httptest.NewRecorder()
+	_, ok := h.runJob(rec, httptest.NewRequest(http.MethodGet, "/api/students", nil), "GetAllStudents",
+		func(ctx context.Context) (interface{}, error) {
+			panic("boom")
+		})
+	if ok || rec.Code != http.StatusInternalServerError {
+		t.Errorf("runJob = %v with status %d, want failed with 500", ok, rec.Code)
+	}
+
+	// The worker survived and runs the next job.
+	data, ok := h.runJob(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/students", nil), "GetAllStudents",
+		func(ctx context.Context) (interface{}, error) {
+			return "done", nil
+		})
+	if !ok || data != "done" {
+		t.Errorf("job after the panic = %v, %v", data, ok)
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+)
+
+type StudentHistoryResponse struct {
+	Data  []domain.StudentAuditEntry `json:"data"`
+	Links PaginationLinks            `json:"links"`
+}
+
+// GetStudentHistory lists the student's audit entries, newest first. Pages
+// continue with before=<id of the last entry>, as in the next link.
+func (h *StudentHandler) GetStudentHistory(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "GetStudentHistory")
+
+	id, ok := h.studentID(w, r, "GetStudentHistory")
+	if !ok {
+		return
+	}
+
+	query, err := parseHistoryQuery(r)
+	if err != nil {
+		logger.Info("invalid query", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger.Debug("fetching student history", "student_id", id)
+
+	data, ok := h.runJob(w, r, "GetStudentHistory", func(ctx context.Context) (interface{}, error) {
+		return h.service.GetStudentHistory(ctx, id, query)
+	})
+	if !ok {
+		return
+	}
+
+	page, ok := data.(*domain.HistoryPage)
+	if !ok || page == nil {
+		logger.Error("unexpected response data type")
+		writeProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := StudentHistoryResponse{
+		Data:  page.Entries,
+		Links: PaginationLinks{Self: r.URL.RequestURI()},
+	}
+	if resp.Data == nil {
+		resp.Data = []domain.StudentAuditEntry{}
+	}
+	if page.HasMore {
+		last := page.Entries[len(page.Entries)-1].ID
+		resp.Links.Next = pageLink(r, map[string]string{"before": strconv.FormatUint(last, 10)})
+	}
+
+	logger.Debug("student history fetched", "student_id", id, "count", len(resp.Data))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func parseHistoryQuery(r *http.Request) (domain.HistoryQuery, error) {
+	var q domain.HistoryQuery
+	values := r.URL.Query()
+
+	var err error
+	if q.Limit, err = parseIntParam(values, "limit"); err != nil {
+		return q, err
+	}
+	if values.Has("limit") && (q.Limit < 1 || q.Limit > domain.MaxStudentPageSize) {
+		return q, fmt.Errorf("limit must be between 1 and %d", domain.MaxStudentPageSize)
+	}
+	if raw := values.Get("before"); raw != "" {
+		if q.BeforeID, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return q, fmt.Errorf("before must be an audit entry id")
+		}
+	}
+	return q, nil
+}
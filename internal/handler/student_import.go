@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"student-api/internal/domain"
+	"student-api/internal/importer"
+	"student-api/internal/logging"
+)
+
+const maxImportBodyBytes = 32 << 20
+
+// ImportStudents upserts students from a CSV or XLSX upload, sent either as
+// the raw request body or as the "file" field of a multipart form. The
+// report is JSON, or with report=csv a downloadable CSV of rejected rows.
+//
+// Like exports, imports run on the request goroutine rather than the worker
+// pool, since they stream the body for longer than a job may take; the
+// import timeout bounds them instead.
+func (h *StudentHandler) ImportStudents(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "ImportStudents")
+	query := r.URL.Query()
+
+	dryRun, err := parseBoolParam(query, "dryRun")
+	if err != nil {
+		logger.Info("invalid query", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	mapping := h.importMapping
+	custom, err := importer.ParseMapping(query.Get("mapping"))
+	if err == nil {
+		mapping, err = mapping.Merge(custom)
+	}
+	if err != nil {
+		logger.Info("invalid mapping", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body, format, err := importSource(w, r)
+	if err != nil {
+		logger.Info("invalid upload", "error", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, errUnsupportedUpload) {
+			status = http.StatusUnsupportedMediaType
+		}
+		writeProblem(w, r, status, err.Error())
+		return
+	}
+	rows, err := importer.NewRows(body, format, mapping)
+	if err != nil {
+		logger.Info("invalid file", "error", err)
+		writeImportFileError(w, r, err)
+		return
+	}
+	defer rows.Close()
+
+	logger.Debug("importing students", "format", format, "dry_run", dryRun)
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeouts.Import)
+	defer cancel()
+	report, err := h.service.ImportStudents(ctx, rows, dryRun)
+	if errors.Is(err, importer.ErrInvalidFile) {
+		err = domain.NewValidationError(err, "%v", err)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(r.Context().Err(), context.Canceled):
+			logger.Info("client disconnected")
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			// Chunks upserted before the deadline stay committed; as rows
+			// are upserted by email, importing the file again completes it
+			logger.Warn("import timed out", "timeout", h.timeouts.Import)
+			writeProblem(w, r, http.StatusGatewayTimeout,
+				"Import timed out; rows imported so far were kept, import the file again to complete it")
+		default:
+			logError(r.Context(), logger, err)
+			writeError(w, r, err)
+		}
+		return
+	}
+
+	logger.Info("import finished", "rows", report.Rows, "created", report.Created,
+		"updated", report.Updated, "rejected", report.Rejected)
+
+	if query.Get("report") == "csv" {
+		w.Header().Set("Content-Type", importer.CSVContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="student-import-errors.csv"`)
+		importer.WriteErrorReport(w, report)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// errUnsupportedUpload is returned for uploads in neither import format.
+var errUnsupportedUpload = fmt.Errorf("upload must be %s or %s", importer.CSVContentType, importer.XLSXContentType)
+
+// importSource locates the uploaded file and its format. A format query
+// parameter overrides what the Content-Type and file name suggest.
+func importSource(w http.ResponseWriter, r *http.Request) (io.Reader, importer.Format, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var body io.Reader = r.Body
+	filename := ""
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid multipart upload: %w", err)
+		}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return nil, "", errors.New(`multipart upload must contain a "file" field`)
+			}
+			if part.FormName() == "file" {
+				body = part
+				filename = part.FileName()
+				mediaType, _, _ = mime.ParseMediaType(part.Header.Get("Content-Type"))
+				break
+			}
+		}
+	}
+
+	if override := r.URL.Query().Get("format"); override != "" {
+		mediaType, filename = "", "upload."+override
+	}
+	format, ok := importer.FormatFor(mediaType, filename)
+	if !ok {
+		return nil, "", errUnsupportedUpload
+	}
+	return body, format, nil
+}
+
+// writeImportFileError responds 422 for files that are not usable student
+// spreadsheets and 400 when the upload itself could not be read.
+func writeImportFileError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, importer.ErrInvalidFile) {
+		writeProblem(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeProblem(w, r, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImportStudentsUploadErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"unsupported type", "text/plain", "email\n", http.StatusUnsupportedMediaType},
+		{"multipart without boundary", "multipart/form-data", "--x\r\n", http.StatusBadRequest},
+		{"multipart without file", "multipart/form-data; boundary=x",
+			"--x\r\nContent-Disposition: form-data; name=\"other\"\r\n\r\nvalue\r\n--x--\r\n", http.StatusBadRequest},
+		{"multipart with unsupported file", "multipart/form-data; boundary=x",
+			"--x\r\nContent-Disposition: form-data; name=\"file\"; filename=\"students.txt\"\r\nContent-Type: text/plain\r\n\r\nemail\r\n--x--\r\n",
+			http.StatusUnsupportedMediaType},
+	}
+	h := NewStudentHandler(nil, nil, Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/students:import", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			h.ImportStudents(rec, r)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+	"student-api/internal/patch"
+)
+
+var acceptPatch = patch.MergePatchContentType + ", " + patch.JSONPatchContentType
+
+// PatchStudent applies an RFC 7396 merge patch or RFC 6902 JSON Patch,
+// selected by Content-Type, to a stored student.
+func (h *StudentHandler) PatchStudent(w http.ResponseWriter, r *http.Request) {
+	logger := logging.Operation(r.Context(), "PatchStudent")
+
+	id, ok := h.studentID(w, r, "PatchStudent")
+	if !ok {
+		return
+	}
+
+	version, ok := h.checkIfMatch(w, r)
+	if !ok {
+		logger.Info("missing or invalid If-Match header")
+		return
+	}
+
+	body, err := readBody(w, r)
+	if err != nil {
+		logger.Info("invalid request body", "error", err)
+		writeProblem(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	studentPatch, err := parseStudentPatch(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		logger.Info("invalid patch", "error", err)
+		if errors.Is(err, errUnsupportedPatchType) {
+			w.Header().Set("Accept-Patch", acceptPatch)
+			writeProblem(w, r, http.StatusUnsupportedMediaType, err.Error())
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger.Debug("patching student", "student_id", id)
+
+	data, ok := h.runJob(w, r, "PatchStudent", func(ctx context.Context) (interface{}, error) {
+		return h.service.PatchStudent(ctx, id, version, studentPatch)
+	})
+	if !ok {
+		return
+	}
+
+	student, ok := data.(*domain.Student)
+	if !ok || student == nil {
+		logger.Error("unexpected response data type")
+		writeProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	logger.Info("student patched", "student_id", id, "version", student.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", studentETag(student.Version))
+	json.NewEncoder(w).Encode(student)
+}
+
+var errUnsupportedPatchType = errors.New("Content-Type must be " + acceptPatch)
+
+// parseStudentPatch checks the patch document up front so syntax errors are
+// reported as 400 before any work is queued, and returns a StudentPatch that
+// maps application failures onto domain errors.
+func parseStudentPatch(contentType string, body []byte) (domain.StudentPatch, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errUnsupportedPatchType
+	}
+
+	var apply domain.StudentPatch
+	switch mediaType {
+	case patch.MergePatchContentType:
+		if !json.Valid(body) {
+			return nil, patch.ErrInvalidPatch
+		}
+		apply = func(document []byte) ([]byte, error) {
+			return patch.MergePatch(document, body)
+		}
+	case patch.JSONPatchContentType:
+		ops, err := patch.DecodeJSONPatch(body)
+		if err != nil {
+			return nil, err
+		}
+		apply = ops.Apply
+	default:
+		return nil, errUnsupportedPatchType
+	}
+
+	return func(document []byte) ([]byte, error) {
+		patched, err := apply(document)
+		switch {
+		case err == nil:
+			return patched, nil
+		case errors.Is(err, patch.ErrTestFailed):
+			return nil, domain.NewConflictError(err, "patch test operation failed: %v", err)
+		default:
+			return nil, domain.NewValidationError(err, "patch cannot be applied: %v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"student-api/internal/domain"
+)
+
+const maxStudentBodyBytes = 1 << 20
+
+// readBody reads at most maxStudentBodyBytes of the request body.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	return io.ReadAll(http.MaxBytesReader(w, r.Body, maxStudentBodyBytes))
+}
+
+// decodeStudent reads a full student representation from the request body.
+func decodeStudent(w http.ResponseWriter, r *http.Request) (*domain.Student, error) {
+	body, err := readBody(w, r)
+	if err != nil {
+		return nil, domain.ErrMalformedJSON
+	}
+	return domain.ParseStudentJSON(body)
+}
+
+// writeDecodeError responds 400 for unparseable bodies and with the mapped
+// domain error status otherwise.
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, domain.ErrMalformedJSON) {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeError(w, r, err)
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"student-api/internal/domain"
+)
+
+type StudentListResponse struct {
+	Data       []domain.Student `json:"data"`
+	Pagination PaginationMeta   `json:"pagination"`
+	Links      PaginationLinks  `json:"links"`
+}
+
+type PaginationMeta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+}
+
+type PaginationLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// parseStudentQuery reads paging, filtering and sorting parameters from the
+// request URL. Sort takes a comma separated list of fields, each optionally
+// prefixed with "-" for descending order, e.g. sort=lastName,-grade.
+func parseStudentQuery(r *http.Request) (domain.StudentQuery, error) {
+	var q domain.StudentQuery
+	values := r.URL.Query()
+
+	var err error
+	if q.Limit, err = parseIntParam(values, "limit"); err != nil {
+		return q, err
+	}
+	if values.Has("limit") && (q.Limit < 1 || q.Limit > domain.MaxStudentPageSize) {
+		return q, fmt.Errorf("limit must be between 1 and %d", domain.MaxStudentPageSize)
+	}
+	if q.Limit == 0 {
+		q.Limit = domain.DefaultStudentPageSize
+	}
+	if q.Offset, err = parseIntParam(values, "offset"); err != nil {
+		return q, err
+	}
+	if q.Offset < 0 {
+		return q, fmt.Errorf("offset must not be negative")
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if !domain.IsSortableStudentField(field) {
+				return q, fmt.Errorf("cannot sort by %q", field)
+			}
+			q.Sort = append(q.Sort, domain.SortField{Field: field, Desc: desc})
+		}
+	}
+
+	if raw := values.Get("cursor"); raw != "" {
+		if values.Has("offset") {
+			return q, fmt.Errorf("cursor and offset cannot be combined")
+		}
+		if !q.SortsByIDOnly() {
+			return q, fmt.Errorf("cursor pagination requires sorting by id")
+		}
+		if q.AfterID, q.BeforeID, err = decodeCursor(raw); err != nil {
+			return q, err
+		}
+	}
+
+	f := &q.Filter
+	if f.MinAge, err = parseOptionalInt(values, "minAge"); err != nil {
+		return q, err
+	}
+	if f.MaxAge, err = parseOptionalInt(values, "maxAge"); err != nil {
+		return q, err
+	}
+	if f.MinGrade, err = parseOptionalFloat(values, "minGrade"); err != nil {
+		return q, err
+	}
+	if f.MaxGrade, err = parseOptionalFloat(values, "maxGrade"); err != nil {
+		return q, err
+	}
+	f.LastNamePrefix = values.Get("lastNamePrefix")
+	f.EmailDomain = strings.TrimPrefix(values.Get("emailDomain"), "@")
+	if f.IncludeDeleted, err = parseBoolParam(values, "includeDeleted"); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+// newStudentListResponse assembles the page body including cursors and
+// next/prev links relative to the request URL.
+func newStudentListResponse(r *http.Request, q domain.StudentQuery, page *domain.StudentPage) StudentListResponse {
+	resp := StudentListResponse{
+		Data: page.Students,
+		Pagination: PaginationMeta{
+			Total: page.Total,
+			Limit: q.Limit,
+		},
+		Links: PaginationLinks{Self: r.URL.RequestURI()},
+	}
+	if resp.Data == nil {
+		resp.Data = []domain.Student{}
+	}
+
+	if q.SortsByIDOnly() && len(page.Students) > 0 {
+		first := page.Students[0].ID
+		last := page.Students[len(page.Students)-1].ID
+
+		backwards := q.BeforeID != 0
+		if (!backwards && page.HasMore) || backwards {
+			resp.Pagination.NextCursor = encodeCursor("after", last)
+		}
+		if (backwards && page.HasMore) || q.AfterID != 0 || (!q.IsKeyset() && q.Offset > 0) {
+			resp.Pagination.PrevCursor = encodeCursor("before", first)
+		}
+	}
+
+	if q.IsKeyset() {
+		if resp.Pagination.NextCursor != "" {
+			resp.Links.Next = pageLink(r, map[string]string{"cursor": resp.Pagination.NextCursor})
+		}
+		if resp.Pagination.PrevCursor != "" {
+			resp.Links.Prev = pageLink(r, map[string]string{"cursor": resp.Pagination.PrevCursor})
+		}
+		return resp
+	}
+
+	offset := q.Offset
+	resp.Pagination.Offset = &offset
+	if int64(q.Offset+len(page.Students)) < page.Total {
+		resp.Links.Next = pageLink(r, map[string]string{"offset": strconv.Itoa(q.Offset + q.Limit)})
+	}
+	if q.Offset > 0 {
+		prev := q.Offset - q.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		resp.Links.Prev = pageLink(r, map[string]string{"offset": strconv.Itoa(prev)})
+	}
+	return resp
+}
+
+func pageLink(r *http.Request, set map[string]string) string {
+	values := r.URL.Query()
+	values.Del("cursor")
+	values.Del("offset")
+	for k, v := range set {
+		values.Set(k, v)
+	}
+	u := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+	return u.String()
+}
+
+func encodeCursor(direction string, id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", direction, id)))
+}
+
+func decodeCursor(cursor string) (after, before uint, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid cursor")
+	}
+	direction, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.ParseUint(idStr, 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		return 0, 0, fmt.Errorf("invalid cursor")
+	}
+	switch direction {
+	case "after":
+		return uint(id), 0, nil
+	case "before":
+		return 0, uint(id), nil
+	}
+	return 0, 0, fmt.Errorf("invalid cursor")
+}
+
+func parseIntParam(values url.Values, name string) (int, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return n, nil
+}
+
+func parseOptionalInt(values url.Values, name string) (*int, error) {
+	if !values.Has(name) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(values.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", name)
+	}
+	return &n, nil
+}
+
+func parseOptionalFloat(values url.Values, name string) (*float64, error) {
+	if !values.Has(name) {
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(values.Get(name), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+	return &n, nil
+}
+
+func parseBoolParam(values url.Values, name string) (bool, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return b, nil
+}
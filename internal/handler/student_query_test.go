@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"student-api/internal/domain"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		direction     string
+		id            uint
+		after, before uint
+	}{
+		{"after", 1, 1, 0},
+		{"before", 42, 0, 42},
+		{"after", 1 << 40, 1 << 40, 0},
+	}
+	for _, tt := range tests {
+		after, before, err := decodeCursor(encodeCursor(tt.direction, tt.id))
+		if err != nil {
+			t.Errorf("decodeCursor(encodeCursor(%q, %d)): %v", tt.direction, tt.id, err)
+			continue
+		}
+		if after != tt.after || before != tt.before {
+			t.Errorf("decodeCursor(encodeCursor(%q, %d)) = %d, %d, want %d, %d",
+				tt.direction, tt.id, after, before, tt.after, tt.before)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, cursor := range []string{
+		"",
+		"not base64!",
+		encode("after"),
+		encode("after:"),
+		encode("after:0"),
+		encode("after:-1"),
+		encode("after:abc"),
+		encode("sideways:1"),
+		encode("after:" + strconv.FormatUint(1<<63, 10) + "0"),
+	} {
+		if _, _, err := decodeCursor(cursor); err == nil {
+			t.Errorf("decodeCursor(%q) succeeded, want error", cursor)
+		}
+	}
+}
+
+func TestParseStudentQuery(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+	floatPtr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		query string
+		want  domain.StudentQuery
+	}{
+		{"", domain.StudentQuery{Limit: domain.DefaultStudentPageSize}},
+		{"limit=5&offset=10", domain.StudentQuery{Limit: 5, Offset: 10}},
+		{
+			"sort=lastName,-grade",
+			domain.StudentQuery{
+				Limit: domain.DefaultStudentPageSize,
+				Sort:  []domain.SortField{{Field: "lastName"}, {Field: "grade", Desc: true}},
+			},
+		},
+		{
+			"cursor=" + encodeCursor("after", 7),
+			domain.StudentQuery{Limit: domain.DefaultStudentPageSize, AfterID: 7},
+		},
+		{
+			"sort=-id&cursor=" + encodeCursor("before", 7),
+			domain.StudentQuery{
+				Limit:    domain.DefaultStudentPageSize,
+				Sort:     []domain.SortField{{Field: "id", Desc: true}},
+				BeforeID: 7,
+			},
+		},
+		{
+			"minAge=18&maxAge=30&minGrade=2.5&maxGrade=4&lastNamePrefix=Sm&emailDomain=@example.com&includeDeleted=true",
+			domain.StudentQuery{
+				Limit: domain.DefaultStudentPageSize,
+				Filter: domain.StudentFilter{
+					MinAge:         intPtr(18),
+					MaxAge:         intPtr(30),
+					MinGrade:       floatPtr(2.5),
+					MaxGrade:       floatPtr(4),
+					LastNamePrefix: "Sm",
+					EmailDomain:    "example.com",
+					IncludeDeleted: true,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/students?"+tt.query, nil)
+		got, err := parseStudentQuery(r)
+		if err != nil {
+			t.Errorf("parseStudentQuery(%q): %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseStudentQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseStudentQueryInvalid(t *testing.T) {
+	for _, query := range []string{
+		"limit=0",
+		"limit=101",
+		"limit=ten",
+		"offset=-1",
+		"sort=password",
+		"sort=-",
+		"cursor=" + encodeCursor("after", 1) + "&offset=5",
+		"cursor=" + encodeCursor("after", 1) + "&sort=lastName",
+		"cursor=bogus",
+		"minAge=old",
+		"maxGrade=A",
+		"includeDeleted=maybe",
+	} {
+		r := httptest.NewRequest("GET", "/api/students?"+query, nil)
+		if _, err := parseStudentQuery(r); err == nil {
+			t.Errorf("parseStudentQuery(%q) succeeded, want error", query)
+		}
+	}
+}
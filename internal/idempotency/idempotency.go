@@ -0,0 +1,62 @@
+// Package idempotency defines how responses to mutating requests are
+// remembered per Idempotency-Key so that retries replay them instead of
+// repeating the change.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// Header is the request header carrying the client-chosen key.
+const Header = "Idempotency-Key"
+
+// MaxKeyLength bounds the accepted key size.
+const MaxKeyLength = 255
+
+var (
+	// ErrInProgress is returned while another request with the same key
+	// is still being processed.
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrFingerprintMismatch is returned when a key is reused for a
+	// different request.
+	ErrFingerprintMismatch = errors.New("idempotency key was used for a different request")
+)
+
+// Response is a stored response to replay.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store persists claimed keys and their responses. Implementations expire
+// entries after a TTL.
+type Store interface {
+	// Acquire claims key for a request with the given fingerprint. It
+	// returns (nil, nil) if the caller now owns the key, the stored
+	// response if the request already completed, ErrInProgress if another
+	// request holds the key, or ErrFingerprintMismatch.
+	Acquire(ctx context.Context, key, fingerprint string) (*Response, error)
+	// Complete stores the response for a claimed key.
+	Complete(ctx context.Context, key string, resp *Response) error
+	// Release gives up a claim without storing a response so the request
+	// can be retried.
+	Release(ctx context.Context, key string) error
+	// Purge deletes expired keys and returns how many were removed.
+	Purge(ctx context.Context) (int64, error)
+}
+
+// Hash returns the hex SHA-256 of parts separated by newlines. It is used
+// both to scope keys and to fingerprint requests.
+func Hash(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,26 @@
+package importer
+
+import "strings"
+
+// formulaPrefixes are the leading characters that make spreadsheet
+// applications treat a cell as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// EscapeFormula prefixes a value that a spreadsheet would run as a formula
+// with an apostrophe, which makes it plain text. Exports apply it to every
+// user-supplied text cell.
+func EscapeFormula(value string) string {
+	if value != "" && strings.IndexByte(formulaPrefixes, value[0]) >= 0 {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeFormula undoes EscapeFormula, so that exported files can be
+// imported again unchanged.
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.IndexByte(formulaPrefixes, value[1]) >= 0 {
+		return value[1:]
+	}
+	return value
+}
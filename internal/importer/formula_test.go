@@ -0,0 +1,30 @@
+package importer
+
+import "testing"
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"", ""},
+		{"Ada", "Ada"},
+		{"O'Neill", "O'Neill"},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"'=already", "'=already"},
+	}
+	for _, tt := range tests {
+		got := EscapeFormula(tt.value)
+		if got != tt.want {
+			t.Errorf("EscapeFormula(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		if tt.value != "'=already" {
+			if back := unescapeFormula(got); back != tt.value {
+				t.Errorf("unescapeFormula(%q) = %q, want %q", got, back, tt.value)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Student fields that can be imported, named as in the JSON representation.
+var importFields = []string{"firstName", "lastName", "email", "age", "grade"}
+
+// defaultAliases maps normalized headers to student fields.
+var defaultAliases = map[string]string{
+	"firstname":    "firstName",
+	"givenname":    "firstName",
+	"lastname":     "lastName",
+	"surname":      "lastName",
+	"familyname":   "lastName",
+	"email":        "email",
+	"emailaddress": "email",
+	"age":          "age",
+	"grade":        "grade",
+}
+
+// Mapping maps spreadsheet column headers to student fields. Headers are
+// matched ignoring case, spaces and punctuation, so "First Name",
+// "first_name" and "FirstName" are the same column.
+type Mapping map[string]string
+
+// NewMapping returns the built-in aliases extended by custom, which maps
+// headers to field names (e.g. {"Nachname": "lastName"}).
+func NewMapping(custom map[string]string) (Mapping, error) {
+	return Mapping(defaultAliases).Merge(custom)
+}
+
+// ParseMapping parses a comma separated list of header=field pairs, as used
+// by the mapping query parameter and the -mapping flag.
+func ParseMapping(spec string) (map[string]string, error) {
+	custom := make(map[string]string)
+	if strings.TrimSpace(spec) == "" {
+		return custom, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		header, field, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(header) == "" {
+			return nil, fmt.Errorf("invalid mapping entry %q, want Header=field", pair)
+		}
+		custom[strings.TrimSpace(header)] = strings.TrimSpace(field)
+	}
+	return custom, nil
+}
+
+// Merge returns a copy of m extended by custom.
+func (m Mapping) Merge(custom map[string]string) (Mapping, error) {
+	merged := make(Mapping, len(m)+len(custom))
+	for header, field := range m {
+		merged[header] = field
+	}
+	for header, field := range custom {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("column %q maps to unknown field %q (want one of %s)", header, field, strings.Join(importFields, ", "))
+		}
+		merged[normalizeHeader(header)] = field
+	}
+	return merged, nil
+}
+
+// columns resolves the header row to the column index of every field.
+func (m Mapping) columns(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		field, ok := m[normalizeHeader(name)]
+		if !ok {
+			continue
+		}
+		if _, dup := columns[field]; dup {
+			return nil, fmt.Errorf("%w: more than one column maps to %s", ErrInvalidFile, field)
+		}
+		columns[field] = i
+	}
+	var missing []string
+	for _, field := range importFields {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: no column for %s", ErrInvalidFile, strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+func isImportField(field string) bool {
+	for _, f := range importFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,214 @@
+// Package importer reads student rows from CSV and XLSX files.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"student-api/internal/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrInvalidFile is returned when a file cannot be read as a student
+// spreadsheet, e.g. because it has no header row or lacks a column.
+var ErrInvalidFile = errors.New("invalid import file")
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+const (
+	CSVContentType  = "text/csv"
+	XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// FormatFor picks the file format from a media type, falling back to the
+// file name's extension. ok is false if neither is recognised.
+func FormatFor(mediaType, filename string) (format Format, ok bool) {
+	switch mediaType {
+	case CSVContentType, "application/csv":
+		return CSV, true
+	case XLSXContentType:
+		return XLSX, true
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV, true
+	case ".xlsx":
+		return XLSX, true
+	}
+	return "", false
+}
+
+// recordReader yields the cells of one row at a time.
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+// Rows converts spreadsheet rows into students. It implements
+// domain.StudentRows.
+type Rows struct {
+	records recordReader
+	columns map[string]int
+	row     int
+	close   func() error
+}
+
+// NewRows reads the header row of r and resolves it with mapping. CSV is
+// streamed; XLSX workbooks are zip archives and are buffered, then read
+// row by row from the first sheet.
+func NewRows(r io.Reader, format Format, mapping Mapping) (*Rows, error) {
+	rows := &Rows{close: func() error { return nil }}
+	switch format {
+	case CSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		cr.ReuseRecord = true
+		rows.records = cr
+	case XLSX:
+		xr, err := newXLSXReader(r)
+		if err != nil {
+			return nil, err
+		}
+		rows.records = xr
+		rows.close = xr.Close
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidFile, format)
+	}
+
+	header, err := rows.records.Read()
+	if err != nil {
+		rows.Close()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: file is empty", ErrInvalidFile)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	rows.row = 1
+	// Excel prefixes UTF-8 CSV exports with a byte order mark.
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	if rows.columns, err = mapping.columns(header); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Next returns the next non-blank row, or io.EOF after the last one.
+func (r *Rows) Next() (*domain.ImportRow, error) {
+	for {
+		record, err := r.records.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidFile, r.row+1, err)
+		}
+		r.row++
+		if isBlank(record) {
+			continue
+		}
+		return r.convert(record), nil
+	}
+}
+
+// Close releases the workbook of an XLSX import.
+func (r *Rows) Close() error {
+	return r.close()
+}
+
+func (r *Rows) convert(record []string) *domain.ImportRow {
+	cell := func(field string) string {
+		if i := r.columns[field]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := &domain.ImportRow{Row: r.row}
+	student := &domain.Student{
+		FirstName: unescapeFormula(cell("firstName")),
+		LastName:  unescapeFormula(cell("lastName")),
+		Email:     unescapeFormula(cell("email")),
+	}
+
+	if value := cell("age"); value == "" {
+		row.Fields = append(row.Fields, domain.FieldError{Field: "age", Message: "is required"})
+	} else if age, err := strconv.Atoi(value); err != nil {
+		row.Fields = append(row.Fields, domain.FieldError{Field: "age", Message: "must be an integer"})
+	} else {
+		student.Age = age
+	}
+
+	if value := cell("grade"); value == "" {
+		row.Fields = append(row.Fields, domain.FieldError{Field: "grade", Message: "is required"})
+	} else if grade, err := strconv.ParseFloat(value, 64); err != nil {
+		row.Fields = append(row.Fields, domain.FieldError{Field: "grade", Message: "must be a number"})
+	} else {
+		student.Grade = grade
+	}
+
+	if len(row.Fields) == 0 {
+		row.Student = student
+	}
+	return row
+}
+
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// xlsxReader adapts excelize's row iterator over the first sheet.
+type xlsxReader struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func newXLSXReader(r io.Reader) (*xlsxReader, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		file.Close()
+		return nil, fmt.Errorf("%w: workbook has no sheets", ErrInvalidFile)
+	}
+	rows, err := file.Rows(sheets[0])
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return &xlsxReader{file: file, rows: rows}, nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return x.rows.Columns()
+}
+
+func (x *xlsxReader) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
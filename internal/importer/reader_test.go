@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"student-api/internal/domain"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func defaultMapping(t *testing.T) Mapping {
+	t.Helper()
+	mapping, err := NewMapping(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mapping
+}
+
+// readAll returns every row of r.
+func readAll(t *testing.T, r io.Reader, format Format, mapping Mapping) []*domain.ImportRow {
+	t.Helper()
+	rows, err := NewRows(r, format, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var all []*domain.ImportRow
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			return all
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, row)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	got, err := ParseMapping(" Nachname = lastName ,Vorname=firstName")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"Nachname": "lastName", "Vorname": "firstName"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMapping = %v, want %v", got, want)
+	}
+	if got, err := ParseMapping("  "); err != nil || len(got) != 0 {
+		t.Errorf("ParseMapping of blank = %v, %v", got, err)
+	}
+	for _, spec := range []string{"lastName", "=lastName", "a=b,c"} {
+		if _, err := ParseMapping(spec); err == nil {
+			t.Errorf("ParseMapping(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestMappingColumns(t *testing.T) {
+	mapping := defaultMapping(t)
+	columns, err := mapping.columns([]string{"E-Mail Address", "Grade", "first_name", "Surname", "AGE", "notes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"email": 0, "grade": 1, "firstName": 2, "lastName": 3, "age": 4}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+
+	custom, err := mapping.Merge(map[string]string{"Nachname": "lastName", "Vorname": "firstName"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := custom.columns([]string{"Vorname", "Nachname", "email", "age", "grade"}); err != nil {
+		t.Errorf("custom mapping: %v", err)
+	}
+	if _, err := mapping.Merge(map[string]string{"Nachname": "surname"}); err == nil {
+		t.Error("Merge accepted an unknown field")
+	}
+
+	for name, header := range map[string][]string{
+		"missing column":   {"firstName", "lastName", "email", "age"},
+		"duplicate column": {"firstName", "lastName", "email", "age", "grade", "Given Name"},
+	} {
+		if _, err := mapping.columns(header); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("%s: error = %v, want ErrInvalidFile", name, err)
+		}
+	}
+}
+
+func TestCSVRows(t *testing.T) {
+	csv := "\ufeffFirst Name,Last Name,Email,Age,Grade\n" +
+		"Ada, Lovelace ,ada@example.com,20,91.5\n" +
+		",,,,\n" +
+		"Alan,Turing,alan@example.com,twenty,\n" +
+		"'=cmd,Hopper,grace@example.com,30,88\n" +
+		"Short,Row\n"
+	rows := readAll(t, strings.NewReader(csv), CSV, defaultMapping(t))
+	if len(rows) != 4 {
+		t.Fatalf("%d rows, want 4 (blank rows skipped)", len(rows))
+	}
+
+	ada := rows[0]
+	if ada.Row != 2 || ada.Student == nil || len(ada.Fields) != 0 {
+		t.Fatalf("row 2 = %+v", ada)
+	}
+	if want := (domain.Student{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Age: 20, Grade: 91.5}); *ada.Student != want {
+		t.Errorf("student = %+v, want %+v", *ada.Student, want)
+	}
+
+	alan := rows[1]
+	if alan.Row != 4 || alan.Student != nil {
+		t.Errorf("row 4 = %+v, want rejected", alan)
+	}
+	want := []domain.FieldError{{Field: "age", Message: "must be an integer"}, {Field: "grade", Message: "is required"}}
+	if !reflect.DeepEqual(alan.Fields, want) {
+		t.Errorf("row 4 errors = %v, want %v", alan.Fields, want)
+	}
+
+	if grace := rows[2]; grace.Student == nil || grace.Student.FirstName != "=cmd" {
+		t.Errorf("escaped formula row = %+v", grace)
+	}
+	if short := rows[3]; short.Row != 6 || short.Student != nil || len(short.Fields) != 2 {
+		t.Errorf("short row = %+v, want age and grade missing", short)
+	}
+}
+
+func TestXLSXRows(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	for i, row := range [][]interface{}{
+		{"Given Name", "Family Name", "Email Address", "Age", "Grade"},
+		{"Ada", "Lovelace", "ada@example.com", 20, 91.5},
+		{},
+		{"Alan", "Turing", "alan@example.com", "x", 70},
+	} {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readAll(t, &buf, XLSX, defaultMapping(t))
+	if len(rows) != 2 {
+		t.Fatalf("%d rows, want 2", len(rows))
+	}
+	if want := (domain.Student{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Age: 20, Grade: 91.5}); rows[0].Student == nil || *rows[0].Student != want {
+		t.Errorf("row 2 = %+v, want %+v", rows[0].Student, want)
+	}
+	if rows[1].Row != 4 || rows[1].Student != nil {
+		t.Errorf("row 4 = %+v, want rejected", rows[1])
+	}
+}
+
+func TestNewRowsInvalidFile(t *testing.T) {
+	mapping := defaultMapping(t)
+	for name, tt := range map[string]struct {
+		body   string
+		format Format
+	}{
+		"empty csv":      {"", CSV},
+		"missing column": {"firstName,lastName\n", CSV},
+		"not a workbook": {"firstName,lastName,email,age,grade\n", XLSX},
+		"unknown format": {"", Format("ods")},
+	} {
+		if _, err := NewRows(strings.NewReader(tt.body), tt.format, mapping); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("%s: error = %v, want ErrInvalidFile", name, err)
+		}
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	tests := []struct {
+		mediaType, filename string
+		want                Format
+		ok                  bool
+	}{
+		{CSVContentType, "", CSV, true},
+		{"application/csv", "", CSV, true},
+		{XLSXContentType, "", XLSX, true},
+		{"application/octet-stream", "Students.XLSX", XLSX, true},
+		{"", "students.csv", CSV, true},
+		{"text/plain", "students.txt", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := FormatFor(tt.mediaType, tt.filename); got != tt.want || ok != tt.ok {
+			t.Errorf("FormatFor(%q, %q) = %q, %v", tt.mediaType, tt.filename, got, ok)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"student-api/internal/domain"
+)
+
+// WriteErrorReport writes the rejected rows of report as CSV with the
+// columns row, field and message.
+func WriteErrorReport(w io.Writer, report *domain.ImportReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"row", "field", "message"})
+	for _, e := range report.Errors {
+		cw.Write([]string{strconv.Itoa(e.Row), e.Field, e.Message})
+	}
+	cw.Flush()
+	return cw.Error()
+}
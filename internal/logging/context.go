@@ -2,11 +2,24 @@ package logging
 
 import (
 	"context"
+	"log/slog"
+	"student-api/internal/domain"
 )
 
 type contextKey string
 
-const traceIDKey contextKey = "traceID"
+const (
+	traceIDKey     contextKey = "traceID"
+	principalKey   contextKey = "principal"
+	requestInfoKey contextKey = "requestInfo"
+	loggerKey      contextKey = "logger"
+)
+
+// requestInfo collects details inner handlers learn about a request for
+// the response line of LogRequest.
+type requestInfo struct {
+	principal *domain.Principal
+}
 
 func AddTraceIDToContext(ctx context.Context, traceID string) context.Context {
 	return context.WithValue(ctx, traceIDKey, traceID)
@@ -18,3 +31,38 @@ func GetTraceIDFromContext(ctx context.Context) string {
 	}
 	return "unknown"
 }
+
+// AddPrincipalToContext records the authenticated caller of the request.
+func AddPrincipalToContext(ctx context.Context, principal *domain.Principal) context.Context {
+	if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok {
+		info.principal = principal
+	}
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// GetPrincipalFromContext returns the authenticated caller, or nil for
+// unauthenticated requests.
+func GetPrincipalFromContext(ctx context.Context) *domain.Principal {
+	principal, _ := ctx.Value(principalKey).(*domain.Principal)
+	return principal
+}
+
+// WithLogger returns a context carrying logger, which FromContext returns.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger of the request, already carrying its
+// trace id, or the default logger outside requests.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Operation returns the context logger with the operation attribute set,
+// e.g. "CreateStudent".
+func Operation(ctx context.Context, operation string) *slog.Logger {
+	return FromContext(ctx).With(slog.String("operation", operation))
+}
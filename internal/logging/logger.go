@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Log output formats accepted by NewLogger.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// NewLogger returns a logger writing records at level or above to w, as
+// JSON objects or as logfmt-style text lines. level is one of debug, info,
+// warn and error, optionally with an offset such as "info+2". A non-nil
+// redactor is applied to every record.
+func NewLogger(w io.Writer, format, level string, redactor *Redactor) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, must be %s or %s", format, FormatJSON, FormatText)
+	}
+	return slog.New(redactor.Handler(handler)), nil
+}
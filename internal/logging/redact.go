@@ -0,0 +1,205 @@
+package logging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"student-api/internal/domain"
+	"unicode/utf8"
+)
+
+// RedactAction says what happens to the value of a redacted attribute.
+type RedactAction string
+
+const (
+	// RedactHash replaces the value with a keyed hash, so equal values can
+	// still be correlated, e.g. "9f86d081884c7d65".
+	RedactHash RedactAction = "hash"
+	// RedactMask keeps the first character, e.g. "J***".
+	RedactMask RedactAction = "mask"
+	// RedactTruncate zeroes the host part of an IP address: IPv4 to /24,
+	// IPv6 to /48.
+	RedactTruncate RedactAction = "truncate"
+	// RedactDrop removes the attribute.
+	RedactDrop RedactAction = "drop"
+	// RedactKeep logs the value unchanged, e.g. to turn off a default rule.
+	RedactKeep RedactAction = "keep"
+)
+
+// redactedValue replaces values a rule cannot transform, such as a client
+// address that is not an IP.
+const redactedValue = "[redacted]"
+
+// Redactor rewrites log attributes by key, wherever they appear in a
+// record, including attributes added with Logger.With and inside groups.
+type Redactor struct {
+	rules   map[string]RedactAction
+	hashKey []byte
+}
+
+// NewRedactor parses rules mapping attribute keys to actions, e.g.
+// "email=hash". hashKey keys the hashes so that they cannot be reversed by
+// hashing guessed values; it may be empty.
+func NewRedactor(rules map[string]string, hashKey string) (*Redactor, error) {
+	r := &Redactor{rules: make(map[string]RedactAction, len(rules)), hashKey: []byte(hashKey)}
+	for key, raw := range rules {
+		action := RedactAction(strings.ToLower(strings.TrimSpace(raw)))
+		switch action {
+		case RedactHash, RedactMask, RedactTruncate, RedactDrop:
+			r.rules[key] = action
+		case RedactKeep:
+		default:
+			return nil, fmt.Errorf("unknown redaction %q for %s, must be hash, mask, truncate, drop or keep", raw, key)
+		}
+	}
+	return r, nil
+}
+
+// Handler returns next with the rules applied to every record.
+func (r *Redactor) Handler(next slog.Handler) slog.Handler {
+	if r == nil || len(r.rules) == 0 {
+		return next
+	}
+	return &redactHandler{next: next, redactor: r}
+}
+
+// String redacts value by the rule for key, for data that also leaves the
+// process outside log records, such as span attributes; ok is false if the
+// value is dropped. A nil Redactor returns value unchanged.
+func (r *Redactor) String(key, value string) (_ string, ok bool) {
+	if r == nil {
+		return value, true
+	}
+	a, ok := r.redact(slog.String(key, value))
+	return a.Value.String(), ok
+}
+
+// redact applies the rule for a.Key; ok is false if the attribute is
+// dropped. Errors have no rule of their own, but the email rule is applied
+// to any address in their text, as database errors quote rejected values.
+func (r *Redactor) redact(a slog.Attr) (_ slog.Attr, ok bool) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := r.redactAll(a.Value.Group())
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}, true
+	}
+	action, found := r.rules[a.Key]
+	if !found {
+		if isError(a) {
+			return r.redactEmails(a), true
+		}
+		return a, true
+	}
+	if action == RedactDrop {
+		return a, false
+	}
+	return slog.String(a.Key, r.apply(action, a.Value.String())), true
+}
+
+// apply returns value redacted by action; a dropped value becomes
+// redactedValue.
+func (r *Redactor) apply(action RedactAction, value string) string {
+	switch action {
+	case RedactHash:
+		return r.hash(value)
+	case RedactMask:
+		return mask(value)
+	case RedactTruncate:
+		return truncateIP(value)
+	default:
+		return redactedValue
+	}
+}
+
+func isError(a slog.Attr) bool {
+	if a.Key == "error" {
+		return true
+	}
+	_, ok := a.Value.Any().(error)
+	return a.Value.Kind() == slog.KindAny && ok
+}
+
+func (r *Redactor) redactEmails(a slog.Attr) slog.Attr {
+	action, found := r.rules["email"]
+	if !found {
+		return a
+	}
+	text := a.Value.String()
+	redacted := domain.ReplaceEmails(text, func(email string) string {
+		return r.apply(action, email)
+	})
+	if redacted == text {
+		return a
+	}
+	return slog.String(a.Key, redacted)
+}
+
+func (r *Redactor) redactAll(attrs []slog.Attr) []slog.Attr {
+	result := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a, ok := r.redact(a); ok {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// hash is case-insensitive, as the values hashed are mostly emails.
+func (r *Redactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func mask(value string) string {
+	first, size := utf8.DecodeRuneInString(value)
+	if size == 0 {
+		return ""
+	}
+	return string(first) + "***"
+}
+
+func truncateIP(value string) string {
+	ip := net.ParseIP(value)
+	switch {
+	case ip == nil:
+		return redactedValue
+	case ip.To4() != nil:
+		return ip.Mask(net.CIDRMask(24, 32)).String()
+	default:
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	}
+}
+
+type redactHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		if a, ok := h.redactor.redact(a); ok {
+			redacted.AddAttrs(a)
+		}
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &redactHandler{next: h.next.WithAttrs(h.redactor.redactAll(attrs)), redactor: h.redactor}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
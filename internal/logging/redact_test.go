@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"student-api/internal/domain"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func newTestLogger(t *testing.T, rules map[string]string) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	redactor, err := NewRedactor(rules, "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	return slog.New(redactor.Handler(slog.NewTextHandler(&buf, nil))), &buf
+}
+
+func TestRedactByKey(t *testing.T) {
+	logger, buf := newTestLogger(t, map[string]string{
+		"email":      "hash",
+		"first_name": "mask",
+		"client_ip":  "truncate",
+		"user_agent": "drop",
+	})
+	logger.With("client_ip", "203.0.113.7").Info("created",
+		slog.Group("student", "first_name", "Alice", "email", "alice@example.com"),
+		"user_agent", "curl/8.0")
+
+	out := buf.String()
+	for _, leaked := range []string{"Alice", "alice@example.com", "203.0.113.7", "curl"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log contains %q: %s", leaked, out)
+		}
+	}
+	for _, want := range []string{"student.first_name=A***", "client_ip=203.0.113.0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log lacks %q: %s", want, out)
+		}
+	}
+}
+
+func TestRedactEmailsInErrors(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'alice@example.com' for key 'students.email'"}
+	err := domain.NewConflictError(fmt.Errorf("insert student: %w", duplicate), "a student with this email already exists")
+
+	logger, buf := newTestLogger(t, map[string]string{"email": "hash"})
+	logger.Error("operation failed", "error", err, "cause", duplicate)
+
+	out := buf.String()
+	if strings.Contains(out, "alice@example.com") {
+		t.Errorf("log contains the email: %s", out)
+	}
+	redactor, _ := NewRedactor(map[string]string{"email": "hash"}, "test-key")
+	if hash := redactor.hash("alice@example.com"); strings.Count(out, hash) != 2 {
+		t.Errorf("log lacks the hashed email %s twice: %s", hash, out)
+	}
+	if !strings.Contains(out, "a student with this email already exists") {
+		t.Errorf("log lacks the error message: %s", out)
+	}
+}
+
+func TestRedactKeepsEmailsInErrorsWithoutEmailRule(t *testing.T) {
+	logger, buf := newTestLogger(t, map[string]string{"first_name": "mask"})
+	logger.Error("operation failed", "error", fmt.Errorf("user alice@example.com"))
+	if !strings.Contains(buf.String(), "alice@example.com") {
+		t.Errorf("email redacted without an email rule: %s", buf.String())
+	}
+}
+
+func TestNewRedactorRejectsUnknownAction(t *testing.T) {
+	if _, err := NewRedactor(map[string]string{"email": "encrypt"}, ""); err == nil {
+		t.Error("NewRedactor accepted an unknown action")
+	}
+}
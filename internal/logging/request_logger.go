@@ -1,25 +1,39 @@
 package logging
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
+	"strings"
+	"student-api/internal/tracing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("logging")
+
 type RequestLogger struct {
-	logger *log.Logger
+	logger   *slog.Logger
+	sampling Sampling
+	// redactor applies the log rules to span attributes holding the same
+	// data, e.g. the client address.
+	redactor *Redactor
 }
 
 type ResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int64
 }
 
 func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
-	return &ResponseWriter{w, http.StatusOK}
+	return &ResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 }
 
 func (rw *ResponseWriter) WriteHeader(code int) {
@@ -27,69 +41,163 @@ func (rw *ResponseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-func NewRequestLogger() *RequestLogger {
-	return &RequestLogger{
-		logger: log.New(os.Stdout, "[API] ", log.Ldate|log.Ltime|log.LUTC),
-	}
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func NewRequestLogger(logger *slog.Logger, sampling Sampling, redactor *Redactor) *RequestLogger {
+	return &RequestLogger{logger: logger, sampling: sampling, redactor: redactor}
 }
 
+// LogRequest runs each request in a server span, continuing the trace of
+// the caller's traceparent or X-Trace-ID, gives it a logger carrying the
+// trace id, which handlers get back with FromContext, and logs one record
+// per completed request. Server errors are logged at error level, the rest
+// at info.
+//
+// Requests are logged at the sample rate of their route. For requests not
+// sampled only warnings and errors are logged, plus the completion record
+// if the response is a client or server error.
 func (l *RequestLogger) LogRequest(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
-		traceID := uuid.New().String()
 
-		// Add trace ID to request context
-		ctx := r.Context()
+		// Continue the caller's trace, if any, in a server span
+		ctx := tracing.Extract(r.Context(), r.Header)
+		spanAttrs := []attribute.KeyValue{
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		}
+		if clientIP, ok := l.redactor.String("client_ip", ClientIP(r)); ok {
+			spanAttrs = append(spanAttrs, attribute.String("client.address", clientIP))
+		}
+		if userAgent, ok := l.redactor.String("user_agent", r.UserAgent()); ok {
+			spanAttrs = append(spanAttrs, attribute.String("user_agent.original", userAgent))
+		}
+		ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(spanAttrs...),
+		)
+		defer span.End()
+		traceID := tracing.TraceID(ctx)
+		if traceID == "" {
+			// Tracing is not set up, e.g. in a CLI command
+			traceID = uuid.New().String()
+		}
+		logger := l.logger.With(slog.String("trace_id", traceID))
+		if span.SpanContext().IsValid() {
+			logger = logger.With(slog.String("span_id", span.SpanContext().SpanID().String()))
+		}
+
+		// Sample by route name; errors are logged either way
+		name := routeName(r)
+		rate := l.sampling.Rate(name)
+		sampled := l.sampling.sample(name)
+		requestLogger := logger
+		if !sampled {
+			requestLogger = slog.New(&minLevelHandler{Handler: logger.Handler(), level: slog.LevelWarn})
+		}
+
+		// Add trace ID and logger to request context
 		ctx = AddTraceIDToContext(ctx, traceID)
+		ctx = WithLogger(ctx, requestLogger)
+		info := &requestInfo{}
+		ctx = context.WithValue(ctx, requestInfoKey, info)
 		r = r.WithContext(ctx)
 
-		// Wrap response writer to capture status code
+		// Wrap response writer to capture status code and size
 		rw := NewResponseWriter(w)
 
-		// Add trace ID to response headers
-		rw.Header().Set("X-Trace-ID", traceID)
+		// Add traceparent and trace ID to response headers
+		tracing.Inject(ctx, rw.Header())
+		rw.Header().Set(tracing.TraceIDHeader, traceID)
 
-		// Log incoming request
-		l.logger.Printf("Request: [TraceID: %s] %s %s from %s",
-			traceID,
-			r.Method,
-			r.URL.Path,
-			getClientIP(r),
+		requestLogger.Debug("request started",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("client_ip", ClientIP(r)),
 		)
 
 		// Handle the request
 		handler.ServeHTTP(rw, r)
 
-		// Calculate duration
-		duration := time.Since(startTime)
-
-		// Log response
-		l.logger.Printf("Response: [TraceID: %s] %s %s from %s - Status: %d - Duration: %v",
-			traceID,
-			r.Method,
-			r.URL.Path,
-			getClientIP(r),
-			rw.statusCode,
-			duration,
-		)
+		route := routeTemplate(r)
+		if route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rw.statusCode))
+		if rw.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.statusCode))
+		}
+		if !sampled && rw.statusCode < http.StatusBadRequest {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", rw.statusCode),
+			slog.Float64("duration_ms", float64(time.Since(startTime).Microseconds())/1000),
+			slog.Int64("bytes", rw.bytes),
+			slog.String("client_ip", ClientIP(r)),
+			slog.String("user_agent", r.UserAgent()),
+		}
+		// Name the API key if one authenticated the request
+		if info.principal != nil && info.principal.KeyID != "" {
+			attrs = append(attrs, slog.String("key_id", info.principal.KeyID))
+		}
+		if rate < 1 {
+			attrs = append(attrs, slog.Float64("sample_rate", rate))
+		}
+		level := slog.LevelInfo
+		if rw.statusCode >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(ctx, level, "request completed", attrs...)
 	})
 }
 
-func (l *RequestLogger) LogOperation(traceID, operation, details string) {
-	l.logger.Printf("Operation: [TraceID: %s] %s - %s", traceID, operation, details)
+// routeName returns the name of the matched route, which is the handler
+// operation, e.g. "GetAllStudents", or "" when no route matched.
+func routeName(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		return route.GetName()
+	}
+	return ""
 }
 
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		return forwarded
+// routeTemplate returns the path template of the matched route, or "" when
+// no route matched.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
 	}
-	// Check X-Real-IP header
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
+	return ""
+}
+
+// ClientIP returns the address of the client. Behind nginx that is
+// X-Real-IP, which nginx overwrites with the peer address; X-Forwarded-For
+// is not used because nginx appends to what the client sent, so its first
+// entry can be anything.
+func ClientIP(r *http.Request) string {
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
 		return realIP
 	}
-	// Fall back to RemoteAddr
+	// Fall back to RemoteAddr, without the port that changes per connection
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
 	return r.RemoteAddr
 }
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		realIP    string
+		forwarded string
+		want      string
+	}{
+		{"connection address", "", "", "192.0.2.1"},
+		{"set by nginx", "203.0.113.7", "", "203.0.113.7"},
+		{"forwarded for is ignored", "", "198.51.100.9", "192.0.2.1"},
+		{"spoofed first forwarded entry", "203.0.113.7", "198.51.100.9, 203.0.113.7", "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/students", nil)
+		r.RemoteAddr = "192.0.2.1:54321"
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := ClientIP(r); got != tt.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogRequestRedactsSpanAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	redactor, err := NewRedactor(map[string]string{"client_ip": "truncate", "user_agent": "drop"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := NewRequestLogger(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, redactor)
+	handler := logger.LogRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("GET", "/api/students", nil)
+	r.Header.Set("X-Real-IP", "203.0.113.7")
+	r.Header.Set("User-Agent", "curl/8.0")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans ended, want 1", len(spans))
+	}
+	attrs := map[string]string{}
+	for _, attr := range spans[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if got := attrs["client.address"]; got != "203.0.113.0" {
+		t.Errorf("client.address = %q, want the truncated address", got)
+	}
+	if got, ok := attrs["user_agent.original"]; ok {
+		t.Errorf("user_agent.original = %q, want it dropped", got)
+	}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+)
+
+// DefaultRoute names the sample rate of routes without their own.
+const DefaultRoute = "default"
+
+// Sampling holds the share of requests logged per route name, from 0 to 1.
+// Routes without an entry use the "default" rate, or log every request.
+type Sampling map[string]float64
+
+// NewSampling parses route=rate pairs, e.g. "GetAllStudents=0.01".
+func NewSampling(values map[string]string) (Sampling, error) {
+	sampling := make(Sampling, len(values))
+	for route, raw := range values {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("sample rate %q of route %s must be between 0 and 1", raw, route)
+		}
+		sampling[route] = rate
+	}
+	return sampling, nil
+}
+
+// Rate returns the share of requests to route that are logged.
+func (s Sampling) Rate(route string) float64 {
+	if rate, ok := s[route]; ok {
+		return rate
+	}
+	if rate, ok := s[DefaultRoute]; ok {
+		return rate
+	}
+	return 1
+}
+
+// sample decides whether a request to route is logged.
+func (s Sampling) sample(route string) bool {
+	rate := s.Rate(route)
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// minLevelHandler drops records below level; unsampled requests log
+// through it so that their warnings and errors are kept.
+type minLevelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h *minLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+func (h *minLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &minLevelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *minLevelHandler) WithGroup(name string) slog.Handler {
+	return &minLevelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewSampling(t *testing.T) {
+	sampling, err := NewSampling(map[string]string{"GetAllStudents": "0.01", DefaultRoute: "0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sampling.Rate("GetAllStudents"); got != 0.01 {
+		t.Errorf("Rate(GetAllStudents) = %v, want 0.01", got)
+	}
+	if got := sampling.Rate("CreateStudent"); got != 0.5 {
+		t.Errorf("Rate(CreateStudent) = %v, want the default 0.5", got)
+	}
+	if got := (Sampling{}).Rate("CreateStudent"); got != 1 {
+		t.Errorf("Rate without a default = %v, want 1", got)
+	}
+
+	for _, raw := range []string{"-0.1", "1.5", "often"} {
+		if _, err := NewSampling(map[string]string{"GetAllStudents": raw}); err == nil {
+			t.Errorf("NewSampling accepted rate %q", raw)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	sampling := Sampling{"Never": 0, "Always": 1}
+	for i := 0; i < 100; i++ {
+		if sampling.sample("Never") {
+			t.Fatal("sampled a route with rate 0")
+		}
+		if !sampling.sample("Always") {
+			t.Fatal("skipped a route with rate 1")
+		}
+	}
+}
+
+func TestMinLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(&minLevelHandler{Handler: slog.NewTextHandler(&buf, nil), level: slog.LevelWarn})
+	logger = logger.With("route", "GetAllStudents").WithGroup("request")
+
+	logger.Info("request completed")
+	logger.Warn("request failed", "status", 500)
+
+	out := buf.String()
+	if strings.Contains(out, "request completed") {
+		t.Errorf("info record was logged: %s", out)
+	}
+	if !strings.Contains(out, "route=GetAllStudents") || !strings.Contains(out, "request.status=500") {
+		t.Errorf("warning lacks its attributes: %s", out)
+	}
+}
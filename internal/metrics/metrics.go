@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "student_api"
+
+// WorkerPoolStats is implemented by anything running jobs on a bounded pool
+// of goroutines fed by a queue.
+type WorkerPoolStats interface {
+	Workers() int
+	BusyWorkers() int
+	QueueLength() int
+	QueueCapacity() int
+}
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestsTotal,
+		m.requestDuration,
+		m.inFlight,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware records request count and latency labelled by the matched route
+// template rather than the raw path, keeping label cardinality bounded.
+// Requests whose handler panics are recorded too, as 500 unless a status was
+// already sent.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			status := rec.status
+			if !completed && !rec.wroteHeader {
+				status = http.StatusInternalServerError
+			}
+			labels := prometheus.Labels{
+				"route":  routeTemplate(r),
+				"method": r.Method,
+				"status": strconv.Itoa(status),
+			}
+			m.requestsTotal.With(labels).Inc()
+			m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+		}()
+		next.ServeHTTP(rec, r)
+		completed = true
+	})
+}
+
+// RegisterDB exports sql.DBStats for the given pool.
+func (m *Metrics) RegisterDB(db *sql.DB, dbName string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// RegisterWorkerPool exports queue depth and worker utilisation for a pool.
+func (m *Metrics) RegisterWorkerPool(name string, pool WorkerPoolStats) {
+	constLabels := prometheus.Labels{"pool": name}
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "worker_pool_workers",
+			Help:        "Number of worker goroutines in the pool.",
+			ConstLabels: constLabels,
+		}, func() float64 { return float64(pool.Workers()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "worker_pool_busy_workers",
+			Help:        "Number of workers currently executing a job.",
+			ConstLabels: constLabels,
+		}, func() float64 { return float64(pool.BusyWorkers()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "worker_pool_queue_length",
+			Help:        "Number of jobs waiting in the queue.",
+			ConstLabels: constLabels,
+		}, func() float64 { return float64(pool.QueueLength()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "worker_pool_queue_capacity",
+			Help:        "Maximum number of jobs the queue can hold.",
+			ConstLabels: constLabels,
+		}, func() float64 { return float64(pool.QueueCapacity()) }),
+	)
+}
+
+// WorkerPoolObserver records job wait and run time histograms for a pool.
+type WorkerPoolObserver struct {
+	rejected prometheus.Counter
+	wait     prometheus.Observer
+	duration prometheus.Observer
+}
+
+// NewWorkerPoolObserver registers the job timing metrics for a named pool.
+func (m *Metrics) NewWorkerPoolObserver(name string) *WorkerPoolObserver {
+	constLabels := prometheus.Labels{"pool": name}
+	rejected := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Name:        "worker_pool_jobs_rejected_total",
+		Help:        "Jobs rejected because the queue was full.",
+		ConstLabels: constLabels,
+	})
+	wait := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Name:        "worker_pool_job_wait_seconds",
+		Help:        "Time jobs spent queued before a worker picked them up.",
+		ConstLabels: constLabels,
+		Buckets:     []float64{.0005, .001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5},
+	})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Name:        "worker_pool_job_duration_seconds",
+		Help:        "Time workers spent executing jobs.",
+		ConstLabels: constLabels,
+		Buckets:     prometheus.DefBuckets,
+	})
+	m.registry.MustRegister(rejected, wait, duration)
+	return &WorkerPoolObserver{rejected: rejected, wait: wait, duration: duration}
+}
+
+func (o *WorkerPoolObserver) JobRejected() {
+	o.rejected.Inc()
+}
+
+func (o *WorkerPoolObserver) JobStarted(wait time.Duration) {
+	o.wait.Observe(wait.Seconds())
+}
+
+func (o *WorkerPoolObserver) JobFinished(duration time.Duration) {
+	o.duration.Observe(duration.Seconds())
+}
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unmatched"
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
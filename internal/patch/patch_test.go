@@ -0,0 +1,399 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assertJSON compares JSON documents by value, ignoring key order.
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result %s is not JSON: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("expected %s is not JSON: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// RFC 7396 Appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("MergePatch(%s, %s): %v", tt.doc, tt.patch, err)
+			continue
+		}
+		assertJSON(t, got, tt.want)
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	for _, patch := range []string{``, `{`, `{"a":1} {"b":2}`} {
+		if _, err := MergePatch([]byte(`{}`), []byte(patch)); !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("MergePatch(%q) error = %v, want ErrInvalidPatch", patch, err)
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc, patch string
+		// want is the patched document, or empty when the patch must fail
+		// with wantErr.
+		want    string
+		wantErr error
+	}{
+		// RFC 6902 Appendix A
+		{
+			name:  "A.1 add object member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:  "A.2 add array element",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "A.3 remove object member",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"remove","path":"/baz"}]`,
+			want:  `{"foo":"bar"}`,
+		},
+		{
+			name:  "A.4 remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "A.5 replace value",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:  `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:  "A.6 move value",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "A.7 move array element",
+			doc:   `{"foo":["all","grass","cows","eat"]}`,
+			patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:  "A.8 test value success",
+			doc:   `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want:  `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:    "A.9 test value error",
+			doc:     `{"baz":"qux"}`,
+			patch:   `[{"op":"test","path":"/baz","value":"bar"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.10 add nested member object",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			want:  `{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		{
+			name:  "A.11 ignore unrecognized elements",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			want:  `{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			name:    "A.12 add to nonexistent target",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:  "A.14 ~ escape ordering",
+			doc:   `{"/":9,"~1":10}`,
+			patch: `[{"op":"test","path":"/~01","value":10}]`,
+			want:  `{"/":9,"~1":10}`,
+		},
+		{
+			name:    "A.15 compare strings and numbers",
+			doc:     `{"/":9,"~1":10}`,
+			patch:   `[{"op":"test","path":"/~01","value":"10"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.16 add array value",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want:  `{"foo":["bar",["abc","def"]]}`,
+		},
+
+		// Pointer escapes
+		{
+			name:  "~1 unescapes to /",
+			doc:   `{"a/b":1}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":2}]`,
+			want:  `{"a/b":2}`,
+		},
+		{
+			name:  "~0 unescapes to ~",
+			doc:   `{"m~n":1}`,
+			patch: `[{"op":"remove","path":"/m~0n"}]`,
+			want:  `{}`,
+		},
+
+		// Array indexes
+		{
+			name:  "add at 0",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/0","value":0}]`,
+			want:  `{"a":[0,1,2]}`,
+		},
+		{
+			name:  "add at len",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/2","value":3}]`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:  "add at -",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/-","value":3}]`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:  "add to empty array",
+			doc:   `{"a":[]}`,
+			patch: `[{"op":"add","path":"/a/0","value":1},{"op":"add","path":"/a/-","value":2}]`,
+			want:  `{"a":[1,2]}`,
+		},
+		{
+			name:    "add past len",
+			doc:     `{"a":[1,2]}`,
+			patch:   `[{"op":"add","path":"/a/3","value":4}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "add at leading zero index",
+			doc:     `{"a":[1,2]}`,
+			patch:   `[{"op":"add","path":"/a/01","value":4}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "add at negative index",
+			doc:     `{"a":[1,2]}`,
+			patch:   `[{"op":"add","path":"/a/-1","value":4}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:  "add into nested array",
+			doc:   `{"a":[[1],[2]]}`,
+			patch: `[{"op":"add","path":"/a/1/-","value":3},{"op":"add","path":"/a/0/0","value":0}]`,
+			want:  `{"a":[[0,1],[2,3]]}`,
+		},
+		{
+			name:  "remove at 0",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"remove","path":"/a/0"}]`,
+			want:  `{"a":[2,3]}`,
+		},
+		{
+			name:  "remove last",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"remove","path":"/a/2"}]`,
+			want:  `{"a":[1,2]}`,
+		},
+		{
+			name:    "remove at len",
+			doc:     `{"a":[1,2,3]}`,
+			patch:   `[{"op":"remove","path":"/a/3"}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "remove at -",
+			doc:     `{"a":[1,2,3]}`,
+			patch:   `[{"op":"remove","path":"/a/-"}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:  "move to 0",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"move","from":"/a/2","path":"/a/0"}]`,
+			want:  `{"a":[3,1,2]}`,
+		},
+		{
+			name:  "move from 0 to -",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"move","from":"/a/0","path":"/a/-"}]`,
+			want:  `{"a":[2,3,1]}`,
+		},
+		{
+			name:  "move to len after removal",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"move","from":"/a/0","path":"/a/2"}]`,
+			want:  `{"a":[2,3,1]}`,
+		},
+		{
+			name:  "move between arrays",
+			doc:   `{"a":[1,2],"b":[]}`,
+			patch: `[{"op":"move","from":"/a/1","path":"/b/-"}]`,
+			want:  `{"a":[1],"b":[2]}`,
+		},
+		{
+			name:    "move from -",
+			doc:     `{"a":[1,2]}`,
+			patch:   `[{"op":"move","from":"/a/-","path":"/b"}]`,
+			wantErr: ErrPathNotFound,
+		},
+
+		// Other operations
+		{
+			name:  "move to same location",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a"}]`,
+			want:  `{"a":{"b":1}}`,
+		},
+		{
+			name:    "move into itself",
+			doc:     `{"a":{"b":1}}`,
+			patch:   `[{"op":"move","from":"/a","path":"/a/c"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "copy is independent of source",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"replace","path":"/b","value":2}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:  "replace whole document",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"","value":{"b":2}}]`,
+			want:  `{"b":2}`,
+		},
+		{
+			name:  "test numbers by value",
+			doc:   `{"a":1,"b":[1.50,{"c":100}]}`,
+			patch: `[{"op":"test","path":"/a","value":1.0},{"op":"test","path":"/b","value":[1.5,{"c":1e2}]}]`,
+			want:  `{"a":1,"b":[1.50,{"c":100}]}`,
+		},
+		{
+			name:    "test missing path",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"test","path":"/b","value":1}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "add and test null",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/b","value":null},{"op":"test","path":"/b","value":null},{"op":"replace","path":"/a","value":null}]`,
+			want:  `{"a":null,"b":null}`,
+		},
+		{
+			name:    "test null against missing",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"test","path":"/b","value":null}]`,
+			wantErr: ErrTestFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := DecodeJSONPatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("DecodeJSONPatch: %v", err)
+			}
+			got, err := p.Apply([]byte(tt.doc))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestJSONPatchAtomic(t *testing.T) {
+	doc := []byte(`{"a":[1,2],"b":{"c":1}}`)
+	original := string(doc)
+	p, err := DecodeJSONPatch([]byte(`[
+		{"op":"add","path":"/a/-","value":3},
+		{"op":"remove","path":"/b/c"},
+		{"op":"replace","path":"/missing","value":1}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Apply(doc)
+	if !errors.Is(err, ErrPathNotFound) {
+		t.Fatalf("Apply error = %v, want ErrPathNotFound", err)
+	}
+	if got != nil {
+		t.Errorf("Apply returned %s on failure, want nil", got)
+	}
+	if string(doc) != original {
+		t.Errorf("document changed to %s", doc)
+	}
+}
+
+func TestDecodeJSONPatchInvalid(t *testing.T) {
+	for _, patch := range []string{
+		`{"op":"add","path":"/a","value":1}`,
+		`[{"op":"frobnicate","path":"/a"}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"replace","path":"/a"}]`,
+		`[{"op":"test","path":"/a"}]`,
+		`[{"op":"remove","path":"a"}]`,
+		`[{"op":"move","from":"a","path":"/b"}]`,
+		`[{"op":"copy","from":"/a","path":"b"}]`,
+		`[] []`,
+	} {
+		if _, err := DecodeJSONPatch([]byte(patch)); !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("DecodeJSONPatch(%s) error = %v, want ErrInvalidPatch", patch, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops buckets that have refilled.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Each replica limits on its
+// own, so behind a load balancer a client effectively gets the limit once
+// per replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	fullAt    map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{fullAt: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A full bucket is the same as a missing one, so idle clients are
+	// forgotten instead of accumulating.
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, fullAt := range s.fullAt {
+			if !fullAt.After(now) {
+				delete(s.fullAt, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	fullAt, result := take(s.fullAt[key], limit, now)
+	s.fullAt[key] = fullAt
+	return result, nil
+}
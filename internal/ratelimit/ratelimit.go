@@ -0,0 +1,136 @@
+// Package ratelimit meters requests per client with token buckets so that
+// one client cannot take all the capacity of the worker pool.
+//
+// A limit such as "100/1m" is a bucket holding 100 tokens that refills at
+// 100 tokens a minute: a client may burst up to 100 requests and then
+// sustain one every 600ms.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoute names the limit shared by all routes without their own.
+const DefaultRoute = "default"
+
+// Limit is the size and refill period of a token bucket.
+type Limit struct {
+	// Requests is the bucket capacity and how many tokens refill per Period.
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses "<requests>/<period>", where the period is a Go
+// duration; a bare unit such as "s" or "m" means one of it.
+func ParseLimit(s string) (Limit, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q must look like 100/1m", s)
+	}
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("limit %q must allow a positive number of requests", s)
+	}
+	if period != "" && strings.IndexAny(period[:1], "0123456789") < 0 {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("limit %q must have a positive period", s)
+	}
+	return Limit{Requests: requests, Period: d}, nil
+}
+
+func (l Limit) String() string {
+	period := l.Period.String()
+	period = strings.TrimSuffix(period, "m0s")
+	if period != l.Period.String() {
+		period += "m"
+	}
+	period = strings.Replace(period, "h0m", "h", 1)
+	return fmt.Sprintf("%d/%s", l.Requests, period)
+}
+
+// interval is the time one token takes to refill.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Limits holds the limit of each route, by route name.
+type Limits map[string]Limit
+
+// NewLimits parses route=limit pairs, e.g. "default=300/1m". Routes
+// without an entry share the "default" limit; without a default they are
+// not limited.
+func NewLimits(values map[string]string) (Limits, error) {
+	limits := make(Limits, len(values))
+	for route, raw := range values {
+		limit, err := ParseLimit(raw)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", route, err)
+		}
+		limits[route] = limit
+	}
+	return limits, nil
+}
+
+// For returns the bucket name and limit applying to route, or false if the
+// route is not limited.
+func (l Limits) For(route string) (string, Limit, bool) {
+	if limit, ok := l[route]; ok {
+		return route, limit, true
+	}
+	limit, ok := l[DefaultRoute]
+	return DefaultRoute, limit, ok
+}
+
+// Result is the state of a bucket after taking a token.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// RetryAfter is how long until a token is available when the request
+	// was not allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets. Take must be atomic per key, so an external
+// store such as Redis needs a script doing the arithmetic in one step; the
+// state it keeps per key is the time at which the bucket will be full.
+type Store interface {
+	// Take removes one token from the bucket of key, if it has one.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// take is the token bucket arithmetic shared by stores. A bucket is
+// represented by the time it is full again ("generic cell rate
+// algorithm"): each request pushes that time one interval further, and a
+// request is refused if that would put it more than a period ahead.
+func take(fullAt time.Time, limit Limit, now time.Time) (time.Time, Result) {
+	interval := limit.interval()
+	if fullAt.Before(now) {
+		fullAt = now
+	}
+	next := fullAt.Add(interval)
+	ahead := next.Sub(now)
+	if ahead > limit.Period {
+		return fullAt, Result{
+			Allowed:    false,
+			Remaining:  0,
+			RetryAfter: ahead - limit.Period,
+			Reset:      fullAt.Sub(now),
+		}
+	}
+	return next, Result{
+		Allowed:   true,
+		Remaining: int(math.Floor(float64(limit.Period-ahead) / float64(interval))),
+		Reset:     ahead,
+	}
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+	}{
+		{"100/1m", Limit{Requests: 100, Period: time.Minute}},
+		{" 5/s ", Limit{Requests: 5, Period: time.Second}},
+		{"10/m", Limit{Requests: 10, Period: time.Minute}},
+		{"1000/1h30m", Limit{Requests: 1000, Period: 90 * time.Minute}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if err != nil {
+			t.Errorf("ParseLimit(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "100", "0/1m", "-1/1m", "x/1m", "100/", "100/0s", "100/-1m", "100/fortnight"} {
+		if _, err := ParseLimit(in); err == nil {
+			t.Errorf("ParseLimit(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestLimitString(t *testing.T) {
+	for _, in := range []string{"100/1m", "5/1s", "1000/1h", "10/1h30m"} {
+		limit, err := ParseLimit(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := limit.String(); got != in {
+			t.Errorf("ParseLimit(%q).String() = %q", in, got)
+		}
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	limits, err := NewLimits(map[string]string{DefaultRoute: "300/1m", "ImportStudents": "5/1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bucket, limit, ok := limits.For("ImportStudents"); !ok || bucket != "ImportStudents" || limit.Requests != 5 {
+		t.Errorf("For(ImportStudents) = %s, %v, %v", bucket, limit, ok)
+	}
+	if bucket, limit, ok := limits.For("GetAllStudents"); !ok || bucket != DefaultRoute || limit.Requests != 300 {
+		t.Errorf("For(GetAllStudents) = %s, %v, %v", bucket, limit, ok)
+	}
+
+	unlimited, err := NewLimits(map[string]string{"ImportStudents": "5/1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := unlimited.For("GetAllStudents"); ok {
+		t.Error("route without a limit or default is limited")
+	}
+
+	if _, err := NewLimits(map[string]string{"ImportStudents": "lots"}); err == nil {
+		t.Error("NewLimits accepted an invalid limit")
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	now := time.Unix(1_700_000_000, 0)
+	takeToken := func(key string) Result {
+		t.Helper()
+		result, err := store.Take(context.Background(), key, limit, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// A new client may burst the whole bucket.
+	for want := 2; want >= 0; want-- {
+		result := takeToken("a")
+		if !result.Allowed || result.Remaining != want {
+			t.Fatalf("burst: %+v, want allowed with %d remaining", result, want)
+		}
+	}
+	if result := takeToken("a"); result.Allowed || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Errorf("empty bucket: %+v, want refused, retry after 1s, reset 3s", result)
+	}
+	// Other clients have their own bucket.
+	if result := takeToken("b"); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("other client: %+v", result)
+	}
+
+	// One token refills per interval.
+	now = now.Add(time.Second)
+	if result := takeToken("a"); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("after one interval: %+v, want allowed with 0 remaining", result)
+	}
+	if result := takeToken("a"); result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("after one interval: %+v, want refused, retry after 1s", result)
+	}
+
+	// An idle bucket refills only up to its capacity.
+	now = now.Add(time.Hour)
+	if result := takeToken("a"); !result.Allowed || result.Remaining != 2 || result.Reset != time.Second {
+		t.Errorf("after idling: %+v, want allowed with 2 remaining, reset 1s", result)
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"student-api/internal/domain"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers we translate into domain errors.
+const (
+	mysqlErrDuplicateEntry   = 1062
+	mysqlErrTooManyConns     = 1040
+	mysqlErrLockWaitTimeout  = 1205
+	mysqlErrDeadlock         = 1213
+	mysqlErrBadNull          = 1048
+	mysqlErrDataTooLong      = 1406
+	mysqlErrOutOfRange       = 1264
+	mysqlErrTruncatedValue   = 1265
+	mysqlErrIncorrectValue   = 1366
+	mysqlErrCheckViolated    = 3819
+	mysqlErrServerShutdown   = 1053
+	mysqlErrQueryInterrupted = 1317
+	mysqlErrMaxExecutionTime = 3024
+)
+
+// translateError maps driver errors onto the domain error taxonomy so that
+// callers never see raw MySQL messages.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrDuplicateEntry:
+			return domain.NewConflictError(err, "a record with this key already exists")
+		case mysqlErrBadNull, mysqlErrDataTooLong, mysqlErrOutOfRange,
+			mysqlErrTruncatedValue, mysqlErrIncorrectValue, mysqlErrCheckViolated:
+			return domain.NewValidationError(err, "the data is invalid")
+		case mysqlErrTooManyConns, mysqlErrLockWaitTimeout, mysqlErrDeadlock,
+			mysqlErrServerShutdown, mysqlErrQueryInterrupted, mysqlErrMaxExecutionTime:
+			return domain.NewUnavailableError(err, "database is temporarily unavailable")
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return domain.NewUnavailableError(err, "database is temporarily unavailable")
+	}
+	return err
+}
+
+// translateStudentError is translateError with the messages worded for
+// the students table, whose only unique key besides the id is the email.
+func translateStudentError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrDuplicateEntry:
+			return domain.NewConflictError(err, "a student with this email already exists")
+		case mysqlErrBadNull, mysqlErrDataTooLong, mysqlErrOutOfRange,
+			mysqlErrTruncatedValue, mysqlErrIncorrectValue, mysqlErrCheckViolated:
+			return domain.NewValidationError(err, "student data is invalid")
+		}
+	}
+	return translateError(err)
+}
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
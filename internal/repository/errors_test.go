@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"student-api/internal/domain"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTranslateError(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: mysqlErrDuplicateEntry, Message: "Duplicate entry 'x' for key 'PRIMARY'"}
+	tooLong := &mysql.MySQLError{Number: mysqlErrDataTooLong, Message: "Data too long"}
+	tests := []struct {
+		name           string
+		err            error
+		kind           error
+		message        string
+		studentMessage string
+	}{
+		{"duplicate entry", duplicate, domain.ErrConflict,
+			"a record with this key already exists", "a student with this email already exists"},
+		{"wrapped duplicate entry", fmt.Errorf("insert: %w", duplicate), domain.ErrConflict,
+			"a record with this key already exists", "a student with this email already exists"},
+		{"data too long", tooLong, domain.ErrValidation, "the data is invalid", "student data is invalid"},
+		{"deadlock", &mysql.MySQLError{Number: mysqlErrDeadlock}, domain.ErrUnavailable,
+			"database is temporarily unavailable", "database is temporarily unavailable"},
+		{"bad connection", driver.ErrBadConn, domain.ErrUnavailable,
+			"database is temporarily unavailable", "database is temporarily unavailable"},
+		{"timeout", context.DeadlineExceeded, domain.ErrUnavailable,
+			"database is temporarily unavailable", "database is temporarily unavailable"},
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			translate func(error) error
+			message   string
+		}{{translateError, tt.message}, {translateStudentError, tt.studentMessage}} {
+			err := c.translate(tt.err)
+			if !errors.Is(err, tt.kind) || domain.ErrorMessage(err) != c.message {
+				t.Errorf("%s: translated to %v (%q), want %v (%q)", tt.name, err, domain.ErrorMessage(err), tt.kind, c.message)
+			}
+		}
+	}
+
+	other := errors.New("something else")
+	if err := translateError(other); err != other {
+		t.Errorf("unknown error translated to %v", err)
+	}
+	if translateError(nil) != nil || translateStudentError(nil) != nil {
+		t.Error("nil error translated to an error")
+	}
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"student-api/internal/apikey"
+	"student-api/internal/domain"
+	"time"
+)
+
+const apiKeyColumns = "id, name, owner, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+type mysqlAPIKeyStore struct {
+	db dbtx
+}
+
+func NewMySQLAPIKeyStore(db *sql.DB) apikey.Store {
+	return &mysqlAPIKeyStore{db: traced(db)}
+}
+
+func (s *mysqlAPIKeyStore) Create(ctx context.Context, key *apikey.Key, hash string) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, key_hash, name, owner, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, key.ID, hash, key.Name, key.Owner, scopes, key.CreatedAt, key.ExpiresAt)
+	return translateError(err)
+}
+
+func (s *mysqlAPIKeyStore) Get(ctx context.Context, id string) (*apikey.Key, string, error) {
+	var hash string
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+", key_hash FROM api_keys WHERE id = ?", id), &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", domain.NewNotFoundError("API key %s not found", id)
+	}
+	if err != nil {
+		return nil, "", translateError(err)
+	}
+	return key, hash, nil
+}
+
+func (s *mysqlAPIKeyStore) List(ctx context.Context) ([]apikey.Key, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at, id")
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	keys := []apikey.Key{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return keys, nil
+}
+
+func (s *mysqlAPIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", at, id)
+	if err != nil {
+		return translateError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+	if n == 0 {
+		return domain.NewNotFoundError("API key %s not found", id)
+	}
+	return nil
+}
+
+func (s *mysqlAPIKeyStore) Touch(ctx context.Context, id string, at, notBefore time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)", at, id, notBefore)
+	return translateError(err)
+}
+
+// scanAPIKey reads apiKeyColumns followed by extra.
+func scanAPIKey(row rowScanner, extra ...interface{}) (*apikey.Key, error) {
+	var (
+		key                            apikey.Key
+		scopes                         []byte
+		expiresAt, lastUsed, revokedAt sql.NullTime
+	)
+	dest := append([]interface{}{&key.ID, &key.Name, &key.Owner, &scopes, &key.CreatedAt, &expiresAt, &lastUsed, &revokedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return nil, err
+	}
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.LastUsedAt = nullTimePtr(lastUsed)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return &key, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"student-api/internal/domain"
+	"testing"
+	"time"
+)
+
+// recordingDB records the statements executed on it and reports
+// rowsAffected for each.
+type recordingDB struct {
+	dbtx
+	rowsAffected int64
+	queries      []string
+	args         [][]interface{}
+}
+
+func (d *recordingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.queries = append(d.queries, query)
+	d.args = append(d.args, args)
+	return driver.RowsAffected(d.rowsAffected), nil
+}
+
+func TestAPIKeyStoreTouchOnlyWritesStaleLastUsed(t *testing.T) {
+	db := &recordingDB{rowsAffected: 1}
+	store := &mysqlAPIKeyStore{db: db}
+	at := time.Now()
+	notBefore := at.Add(-time.Minute)
+	if err := store.Touch(context.Background(), "abcdefghijkl", at, notBefore); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.queries) != 1 || !strings.Contains(db.queries[0], "(last_used_at IS NULL OR last_used_at < ?)") {
+		t.Fatalf("queries = %q, want a conditional update", db.queries)
+	}
+	if args := db.args[0]; len(args) != 3 || args[0] != at || args[1] != "abcdefghijkl" || args[2] != notBefore {
+		t.Errorf("args = %v, want at, id, notBefore", args)
+	}
+}
+
+func TestAPIKeyStoreRevoke(t *testing.T) {
+	store := &mysqlAPIKeyStore{db: &recordingDB{rowsAffected: 1}}
+	if err := store.Revoke(context.Background(), "abcdefghijkl", time.Now()); err != nil {
+		t.Errorf("revoking an active key: %v", err)
+	}
+
+	store = &mysqlAPIKeyStore{db: &recordingDB{rowsAffected: 0}}
+	if err := store.Revoke(context.Background(), "abcdefghijkl", time.Now()); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("revoking a revoked or unknown key: %v, want not found", err)
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"student-api/internal/idempotency"
+	"time"
+)
+
+type mysqlIdempotencyStore struct {
+	db  dbtx
+	ttl time.Duration
+	// lockTimeout is how long a claim blocks other requests before it is
+	// considered abandoned, e.g. because the instance holding it crashed.
+	lockTimeout time.Duration
+}
+
+func NewMySQLIdempotencyStore(db *sql.DB, ttl, lockTimeout time.Duration) idempotency.Store {
+	return &mysqlIdempotencyStore{db: traced(db), ttl: ttl, lockTimeout: lockTimeout}
+}
+
+func (s *mysqlIdempotencyStore) Acquire(ctx context.Context, key, fingerprint string) (*idempotency.Response, error) {
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (idem_key, fingerprint, locked_until, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, key, fingerprint, now.Add(s.lockTimeout), now, now.Add(s.ttl))
+	if err == nil {
+		return nil, nil
+	}
+	if !isDuplicateEntry(err) {
+		return nil, translateError(err)
+	}
+
+	var (
+		storedFingerprint string
+		status            sql.NullInt64
+		headers           []byte
+		body              []byte
+		lockedUntil       sql.NullTime
+		expiresAt         time.Time
+	)
+	err = s.db.QueryRowContext(ctx, `
+		SELECT fingerprint, status_code, headers, body, locked_until, expires_at
+		FROM idempotency_keys WHERE idem_key = ?
+	`, key).Scan(&storedFingerprint, &status, &headers, &body, &lockedUntil, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Purged between the insert and the read; let the client retry.
+		return nil, idempotency.ErrInProgress
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if !expiresAt.After(now) {
+		// An expired key is free for reuse by any request.
+		return nil, s.reclaim(ctx, key, "expires_at <= ?", fingerprint, now)
+	}
+	if storedFingerprint != fingerprint {
+		return nil, idempotency.ErrFingerprintMismatch
+	}
+	if status.Valid {
+		resp := &idempotency.Response{Status: int(status.Int64), Header: http.Header{}, Body: body}
+		if len(headers) > 0 {
+			if err := json.Unmarshal(headers, &resp.Header); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+	if lockedUntil.Valid && lockedUntil.Time.After(now) {
+		return nil, idempotency.ErrInProgress
+	}
+	return nil, s.reclaim(ctx, key, "status_code IS NULL AND locked_until <= ?", fingerprint, now)
+}
+
+// reclaim takes over an expired or abandoned key. condition, whose single
+// placeholder is bound to now, makes it a compare-and-swap so that of
+// several concurrent retries only one wins.
+func (s *mysqlIdempotencyStore) reclaim(ctx context.Context, key, condition, fingerprint string, now time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET fingerprint = ?, status_code = NULL, headers = NULL, body = NULL,
+			locked_until = ?, created_at = ?, expires_at = ?
+		WHERE idem_key = ? AND `+condition,
+		fingerprint, now.Add(s.lockTimeout), now, now.Add(s.ttl), key, now)
+	if err != nil {
+		return translateError(err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return idempotency.ErrInProgress
+	}
+	return nil
+}
+
+func (s *mysqlIdempotencyStore) Complete(ctx context.Context, key string, resp *idempotency.Response) error {
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = ?, headers = ?, body = ?, locked_until = NULL
+		WHERE idem_key = ?
+	`, resp.Status, headers, resp.Body, key)
+	return translateError(err)
+}
+
+func (s *mysqlIdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE idem_key = ? AND status_code IS NULL", key)
+	return translateError(err)
+}
+
+func (s *mysqlIdempotencyStore) Purge(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?", time.Now())
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return result.RowsAffected()
+}
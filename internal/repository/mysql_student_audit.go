@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+	"time"
+)
+
+const auditSelect = "SELECT id, student_id, action, actor, trace_id, version, changes, created_at FROM student_audit"
+
+// newAuditEntry describes a write to a student made on behalf of the actor
+// and trace in ctx. before is nil for a create and after nil for a purge.
+func newAuditEntry(ctx context.Context, action domain.AuditAction, before, after *domain.Student) *domain.StudentAuditEntry {
+	entry := &domain.StudentAuditEntry{
+		Action:  action,
+		Actor:   domain.ActorFromContext(ctx),
+		Changes: domain.AuditChanges(before, after),
+		At:      time.Now(),
+	}
+	if traceID := logging.GetTraceIDFromContext(ctx); traceID != "unknown" {
+		entry.TraceID = traceID
+	}
+	if after != nil {
+		entry.StudentID, entry.Version = after.ID, after.Version
+	} else {
+		entry.StudentID, entry.Version = before.ID, before.Version
+	}
+	return entry
+}
+
+// recordAudit appends entries to the audit trail. It is called with the
+// repository of the transaction that made the writes, so both commit or
+// roll back together.
+func (r *mysqlStudentRepository) recordAudit(ctx context.Context, entries ...*domain.StudentAuditEntry) error {
+	for start := 0; start < len(entries); start += insertChunkSize {
+		chunk := entries[start:min(start+insertChunkSize, len(entries))]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*7)
+		for i, entry := range chunk {
+			changes, err := json.Marshal(entry.Changes)
+			if err != nil {
+				return err
+			}
+			var traceID sql.NullString
+			if entry.TraceID != "" {
+				traceID = sql.NullString{String: entry.TraceID, Valid: true}
+			}
+			placeholders[i] = "(?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, entry.StudentID, entry.Action, entry.Actor, traceID, entry.Version, changes, entry.At)
+		}
+		query := "INSERT INTO student_audit (student_id, action, actor, trace_id, version, changes, created_at) VALUES " +
+			strings.Join(placeholders, ", ")
+		if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+			return translateError(err)
+		}
+	}
+	return nil
+}
+
+func (r *mysqlStudentRepository) History(ctx context.Context, id uint, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	query := auditSelect + " WHERE student_id = ?"
+	args := []interface{}{id}
+	if q.BeforeID != 0 {
+		query += " AND id < ?"
+		args = append(args, q.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, q.Limit+1)
+
+	entries, err := r.queryAudit(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	page := &domain.HistoryPage{Entries: entries}
+	if len(entries) > q.Limit {
+		page.HasMore = true
+		page.Entries = entries[:q.Limit]
+	}
+	return page, nil
+}
+
+func (r *mysqlStudentRepository) AuditSince(ctx context.Context, id uint, since time.Time) ([]domain.StudentAuditEntry, error) {
+	return r.queryAudit(ctx, auditSelect+" WHERE student_id = ? AND created_at > ? ORDER BY id DESC", id, since)
+}
+
+func (r *mysqlStudentRepository) queryAudit(ctx context.Context, query string, args ...interface{}) ([]domain.StudentAuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var entries []domain.StudentAuditEntry
+	for rows.Next() {
+		var (
+			entry   domain.StudentAuditEntry
+			traceID sql.NullString
+			changes []byte
+		)
+		err := rows.Scan(&entry.ID, &entry.StudentID, &entry.Action, &entry.Actor, &traceID, &entry.Version, &changes, &entry.At)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		entry.TraceID = traceID.String
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return entries, nil
+}
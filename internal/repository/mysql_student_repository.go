@@ -1,115 +1,326 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"strings"
 	"student-api/internal/domain"
 	"time"
 )
 
+// dbtx is the subset of *sql.DB and *sql.Tx the repository queries through.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertChunkSize bounds the rows per multi-row INSERT, keeping statements
+// well below max_allowed_packet and the 65535 placeholder limit.
+const insertChunkSize = 500
+
+// studentSelect reads the columns scanStudent expects, in order.
+const studentSelect = "SELECT id, first_name, last_name, email, age, grade, version, created_at, updated_at, deleted_at FROM students"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStudent(row rowScanner, student *domain.Student) error {
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&student.ID,
+		&student.FirstName,
+		&student.LastName,
+		&student.Email,
+		&student.Age,
+		&student.Grade,
+		&student.Version,
+		&student.CreatedAt,
+		&student.UpdatedAt,
+		&deletedAt,
+	)
+	student.DeletedAt = nil
+	if deletedAt.Valid {
+		student.DeletedAt = &deletedAt.Time
+	}
+	return err
+}
+
 type mysqlStudentRepository struct {
-	db *sql.DB
+	db dbtx
+	// conn is the pool transactions are started from; nil inside one.
+	conn *sql.DB
 }
 
 func NewMySQLStudentRepository(db *sql.DB) domain.StudentRepository {
-	return &mysqlStudentRepository{db: db}
+	return &mysqlStudentRepository{db: traced(db), conn: db}
 }
 
-func (r *mysqlStudentRepository) Create(student *domain.Student) error {
-	query := `
-		INSERT INTO students (first_name, last_name, email, age, grade, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`
-	now := time.Now()
-	result, err := r.db.Exec(query,
-		student.FirstName,
-		student.LastName,
-		student.Email,
-		student.Age,
-		student.Grade,
-		now,
-		now,
-	)
+func (r *mysqlStudentRepository) InTransaction(ctx context.Context, fn func(repo domain.StudentRepository) error) error {
+	return r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		return fn(tx)
+	})
+}
+
+// inTx runs fn in the transaction r is bound to, or in a new one, so every
+// write commits or rolls back together with its audit entries.
+func (r *mysqlStudentRepository) inTx(ctx context.Context, fn func(tx *mysqlStudentRepository) error) error {
+	if r.conn == nil {
+		// Already inside a transaction; join it.
+		return fn(r)
+	}
+	tx, err := r.conn.BeginTx(ctx, nil)
 	if err != nil {
+		return translateStudentError(err)
+	}
+	if err := fn(&mysqlStudentRepository{db: traced(tx)}); err != nil {
+		tx.Rollback()
 		return err
 	}
+	if err := tx.Commit(); err != nil {
+		return translateStudentError(err)
+	}
+	return nil
+}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
+func (r *mysqlStudentRepository) Create(ctx context.Context, student *domain.Student) error {
+	return r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		query := `
+			INSERT INTO students (first_name, last_name, email, age, grade, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`
+		now := time.Now()
+		result, err := tx.db.ExecContext(ctx, query,
+			student.FirstName,
+			student.LastName,
+			student.Email,
+			student.Age,
+			student.Grade,
+			now,
+			now,
+		)
+		if err != nil {
+			return translateStudentError(err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return translateStudentError(err)
+		}
+
+		student.ID = uint(id)
+		student.CreatedAt = now
+		student.UpdatedAt = now
+		student.Version = 1
+		return tx.recordAudit(ctx, newAuditEntry(ctx, domain.AuditCreate, nil, student))
+	})
+}
+
+func (r *mysqlStudentRepository) CreateMany(ctx context.Context, students []*domain.Student) error {
+	if len(students) == 0 {
+		return nil
+	}
+	err := r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		if err := tx.insertMany(ctx, students); err != nil {
+			return err
+		}
+		entries := make([]*domain.StudentAuditEntry, len(students))
+		for i, student := range students {
+			entries[i] = newAuditEntry(ctx, domain.AuditCreate, nil, student)
+		}
+		return tx.recordAudit(ctx, entries...)
+	})
+	if err != nil && r.conn != nil {
+		// The transaction was rolled back, taking the chunks inserted before
+		// the failing one with it
+		for _, student := range students {
+			student.ID = 0
+			student.Version = 0
+			student.CreatedAt = time.Time{}
+			student.UpdatedAt = time.Time{}
+		}
+	}
+	return err
+}
+
+func (r *mysqlStudentRepository) insertMany(ctx context.Context, students []*domain.Student) error {
+	// Rows of one INSERT get consecutive auto-increment values, spaced by
+	// auto_increment_increment (not 1 on some replicated setups).
+	var step int64
+	if err := r.db.QueryRowContext(ctx, "SELECT @@SESSION.auto_increment_increment").Scan(&step); err != nil {
+		return translateStudentError(err)
 	}
 
-	student.ID = uint(id)
-	student.CreatedAt = now
-	student.UpdatedAt = now
+	now := time.Now()
+	for start := 0; start < len(students); start += insertChunkSize {
+		chunk := students[start:min(start+insertChunkSize, len(students))]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*7)
+		for i, student := range chunk {
+			placeholders[i] = "(?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, student.FirstName, student.LastName, student.Email, student.Age, student.Grade, now, now)
+		}
+		query := "INSERT INTO students (first_name, last_name, email, age, grade, created_at, updated_at) VALUES " +
+			strings.Join(placeholders, ", ")
+
+		result, err := r.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return translateStudentError(err)
+		}
+		// LAST_INSERT_ID is the id of the first row of the statement.
+		firstID, err := result.LastInsertId()
+		if err != nil {
+			return translateStudentError(err)
+		}
+		for i, student := range chunk {
+			student.ID = uint(firstID + int64(i)*step)
+			student.CreatedAt = now
+			student.UpdatedAt = now
+			student.Version = 1
+		}
+	}
 	return nil
 }
 
-func (r *mysqlStudentRepository) GetByID(id uint) (*domain.Student, error) {
-	query := `
-		SELECT id, first_name, last_name, email, age, grade, created_at, updated_at
-		FROM students
-		WHERE id = ?
-	`
+func (r *mysqlStudentRepository) GetByID(ctx context.Context, id uint, includeDeleted bool) (*domain.Student, error) {
+	return r.getStudent(ctx, id, includeDeleted, false)
+}
+
+// getStudent reads a student, with forUpdate locking the row until the
+// transaction ends so the state an audit entry diffs against stays current.
+func (r *mysqlStudentRepository) getStudent(ctx context.Context, id uint, includeDeleted, forUpdate bool) (*domain.Student, error) {
+	query := studentSelect + " WHERE id = ?"
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
 	student := &domain.Student{}
-	err := r.db.QueryRow(query, id).Scan(
-		&student.ID,
-		&student.FirstName,
-		&student.LastName,
-		&student.Email,
-		&student.Age,
-		&student.Grade,
-		&student.CreatedAt,
-		&student.UpdatedAt,
-	)
+	err := scanStudent(r.db.QueryRowContext(ctx, query, id), student)
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return nil, domain.NewNotFoundError("student %d not found", id)
 	}
 	if err != nil {
-		return nil, err
+		return nil, translateStudentError(err)
 	}
 	return student, nil
 }
 
-func (r *mysqlStudentRepository) GetAll() ([]domain.Student, error) {
-	query := `
-		SELECT id, first_name, last_name, email, age, grade, created_at, updated_at
-		FROM students
-	`
-	rows, err := r.db.Query(query)
+func (r *mysqlStudentRepository) GetAll(ctx context.Context, q domain.StudentQuery) (*domain.StudentPage, error) {
+	where, args := buildStudentFilter(q.Filter)
+
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM students" + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, translateStudentError(err)
+	}
+
+	// Keyset paging narrows the filtered set further and walks backwards
+	// (reversed ORDER BY) when paging before a cursor.
+	reverse := false
+	if q.IsKeyset() {
+		cond, cursorArg := "id > ?", q.AfterID
+		if q.BeforeID != 0 {
+			cond, cursorArg = "id < ?", q.BeforeID
+			reverse = true
+		}
+		if q.IDDescending() {
+			if cond == "id > ?" {
+				cond = "id < ?"
+			} else {
+				cond = "id > ?"
+			}
+		}
+		if where == "" {
+			where = " WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+		args = append(args, cursorArg)
+	}
+
+	query := studentSelect + where + buildStudentOrderBy(q.Sort, reverse) + " LIMIT ?"
+	args = append(args, q.Limit+1)
+	if !q.IsKeyset() && q.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, q.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateStudentError(err)
 	}
 	defer rows.Close()
 
-	var students []domain.Student
+	students := make([]domain.Student, 0, q.Limit+1)
 	for rows.Next() {
 		var student domain.Student
-		err := rows.Scan(
-			&student.ID,
-			&student.FirstName,
-			&student.LastName,
-			&student.Email,
-			&student.Age,
-			&student.Grade,
-			&student.CreatedAt,
-			&student.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
+		if err := scanStudent(rows, &student); err != nil {
+			return nil, translateStudentError(err)
 		}
 		students = append(students, student)
 	}
-	return students, nil
+	if err := rows.Err(); err != nil {
+		return nil, translateStudentError(err)
+	}
+
+	page := &domain.StudentPage{Total: total}
+	if len(students) > q.Limit {
+		page.HasMore = true
+		students = students[:q.Limit]
+	}
+	if reverse {
+		for i, j := 0, len(students)-1; i < j; i, j = i+1, j-1 {
+			students[i], students[j] = students[j], students[i]
+		}
+	}
+	page.Students = students
+	return page, nil
 }
 
-func (r *mysqlStudentRepository) Update(student *domain.Student) error {
-	query := `
-		UPDATE students
-		SET first_name = ?, last_name = ?, email = ?, age = ?, grade = ?, updated_at = ?
-		WHERE id = ?
-	`
+func (r *mysqlStudentRepository) Stream(ctx context.Context, filter domain.StudentFilter, sort []domain.SortField, fn func(*domain.Student) error) error {
+	where, args := buildStudentFilter(filter)
+	query := studentSelect + where + buildStudentOrderBy(sort, false)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return translateStudentError(err)
+	}
+	defer rows.Close()
+
+	var student domain.Student
+	for rows.Next() {
+		if err := scanStudent(rows, &student); err != nil {
+			return translateStudentError(err)
+		}
+		if err := fn(&student); err != nil {
+			return err
+		}
+	}
+	return translateStudentError(rows.Err())
+}
+
+func (r *mysqlStudentRepository) Update(ctx context.Context, student *domain.Student) error {
+	return r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		before, err := tx.getStudent(ctx, student.ID, false, true)
+		if err != nil {
+			return err
+		}
+		if err := tx.update(ctx, student); err != nil {
+			return err
+		}
+		return tx.recordAudit(ctx, newAuditEntry(ctx, domain.AuditUpdate, before, student))
+	})
+}
+
+func (r *mysqlStudentRepository) update(ctx context.Context, student *domain.Student) error {
 	now := time.Now()
-	_, err := r.db.Exec(query,
+	query := "UPDATE students SET first_name = ?, last_name = ?, email = ?, age = ?, grade = ?, " +
+		"updated_at = ?, " + nextVersion + " WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{
 		student.FirstName,
 		student.LastName,
 		student.Email,
@@ -117,16 +328,366 @@ func (r *mysqlStudentRepository) Update(student *domain.Student) error {
 		student.Grade,
 		now,
 		student.ID,
-	)
+	}
+	if student.Version != 0 {
+		query += " AND version = ?"
+		args = append(args, student.Version)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return translateStudentError(err)
+	}
+	version, err := r.writtenVersion(ctx, result, student.ID)
 	if err != nil {
 		return err
 	}
 	student.UpdatedAt = now
+	student.Version = version
 	return nil
 }
 
-func (r *mysqlStudentRepository) Delete(id uint) error {
-	query := "DELETE FROM students WHERE id = ?"
-	_, err := r.db.Exec(query, id)
-	return err
+func (r *mysqlStudentRepository) UpdateFields(ctx context.Context, id uint, version uint64, changes domain.StudentChanges) (time.Time, uint64, error) {
+	var updatedAt time.Time
+	var newVersion uint64
+	err := r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		before, err := tx.getStudent(ctx, id, false, true)
+		if err != nil {
+			return err
+		}
+		if updatedAt, newVersion, err = tx.updateFields(ctx, id, version, changes); err != nil {
+			return err
+		}
+		after := *before
+		changes.Apply(&after)
+		after.UpdatedAt = updatedAt
+		after.Version = newVersion
+		return tx.recordAudit(ctx, newAuditEntry(ctx, domain.AuditUpdate, before, &after))
+	})
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return updatedAt, newVersion, nil
+}
+
+func (r *mysqlStudentRepository) updateFields(ctx context.Context, id uint, version uint64, changes domain.StudentChanges) (time.Time, uint64, error) {
+	var sets []string
+	var args []interface{}
+	if changes.FirstName != nil {
+		sets = append(sets, "first_name = ?")
+		args = append(args, *changes.FirstName)
+	}
+	if changes.LastName != nil {
+		sets = append(sets, "last_name = ?")
+		args = append(args, *changes.LastName)
+	}
+	if changes.Email != nil {
+		sets = append(sets, "email = ?")
+		args = append(args, *changes.Email)
+	}
+	if changes.Age != nil {
+		sets = append(sets, "age = ?")
+		args = append(args, *changes.Age)
+	}
+	if changes.Grade != nil {
+		sets = append(sets, "grade = ?")
+		args = append(args, *changes.Grade)
+	}
+
+	now := time.Now()
+	sets = append(sets, "updated_at = ?", nextVersion)
+	args = append(args, now, id, version)
+
+	query := "UPDATE students SET " + strings.Join(sets, ", ") + " WHERE id = ? AND version = ? AND deleted_at IS NULL"
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return time.Time{}, 0, translateStudentError(err)
+	}
+	newVersion, err := r.writtenVersion(ctx, result, id)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return now, newVersion, nil
+}
+
+// nextVersion bumps the row version and, through LAST_INSERT_ID(expr),
+// hands the new value back in the OK packet so no extra read is needed.
+const nextVersion = "version = LAST_INSERT_ID(version + 1)"
+
+// writtenVersion returns the version set by an UPDATE using nextVersion.
+// When no row matched it tells a missing student (NotFound) apart from a
+// stale version (PreconditionFailed).
+func (r *mysqlStudentRepository) writtenVersion(ctx context.Context, result sql.Result, id uint) (uint64, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, translateStudentError(err)
+	}
+	if n == 0 {
+		var exists bool
+		err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM students WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists)
+		if err != nil {
+			return 0, translateStudentError(err)
+		}
+		if !exists {
+			return 0, domain.NewNotFoundError("student %d not found", id)
+		}
+		return 0, domain.NewPreconditionFailedError("student %d has been modified since it was read", id)
+	}
+	version, err := result.LastInsertId()
+	if err != nil {
+		return 0, translateStudentError(err)
+	}
+	return uint64(version), nil
+}
+
+func (r *mysqlStudentRepository) Delete(ctx context.Context, id uint) error {
+	return r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		before, err := tx.getStudent(ctx, id, false, true)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		query := "UPDATE students SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL"
+		result, err := tx.db.ExecContext(ctx, query, now, now, id)
+		if err != nil {
+			return translateStudentError(err)
+		}
+		if err := requireAffected(result, id); err != nil {
+			return err
+		}
+		after := *before
+		after.DeletedAt = &now
+		after.UpdatedAt = now
+		after.Version++
+		return tx.recordAudit(ctx, newAuditEntry(ctx, domain.AuditDelete, before, &after))
+	})
+}
+
+func (r *mysqlStudentRepository) Restore(ctx context.Context, id uint) error {
+	return r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		before, err := tx.getStudent(ctx, id, true, true)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return domain.NewConflictError(nil, "student %d is not deleted", id)
+		}
+		now := time.Now()
+		query := "UPDATE students SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ?"
+		if _, err := tx.db.ExecContext(ctx, query, now, id); err != nil {
+			// Another live student may have taken the email meanwhile.
+			return translateStudentError(err)
+		}
+		after := *before
+		after.DeletedAt = nil
+		after.UpdatedAt = now
+		after.Version++
+		return tx.recordAudit(ctx, newAuditEntry(ctx, domain.AuditRestore, before, &after))
+	})
+}
+
+// scrubAuditQuery blanks the personal fields in the changes of audit entries,
+// as ScrubPersonalData does; JSON_REPLACE leaves fields an entry did not
+// change absent.
+var scrubAuditQuery = func() string {
+	paths := make([]string, len(domain.PersonalAuditFields))
+	for i, field := range domain.PersonalAuditFields {
+		paths[i] = "'$." + field + "', JSON_OBJECT('from', NULL, 'to', NULL)"
+	}
+	return "UPDATE student_audit SET changes = JSON_REPLACE(changes, " + strings.Join(paths, ", ") + ")"
+}()
+
+// purgeBatchSize bounds the rows removed per transaction so a large purge
+// does not hold locks for long.
+const purgeBatchSize = 1000
+
+func (r *mysqlStudentRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		var purged int
+		err := r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+			students, err := tx.queryStudents(ctx,
+				studentSelect+" WHERE deleted_at IS NOT NULL AND deleted_at < ? ORDER BY id LIMIT ? FOR UPDATE", cutoff, purgeBatchSize)
+			if err != nil || len(students) == 0 {
+				return err
+			}
+
+			ids := make([]interface{}, len(students))
+			entries := make([]*domain.StudentAuditEntry, len(students))
+			for i, student := range students {
+				ids[i] = student.ID
+				entries[i] = newAuditEntry(ctx, domain.AuditPurge, student, nil)
+				entries[i].ScrubPersonalData()
+			}
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+			if _, err := tx.db.ExecContext(ctx, "DELETE FROM students WHERE id IN ("+placeholders+")", ids...); err != nil {
+				return translateStudentError(err)
+			}
+			if _, err := tx.db.ExecContext(ctx, scrubAuditQuery+" WHERE student_id IN ("+placeholders+")", ids...); err != nil {
+				return translateStudentError(err)
+			}
+			purged = len(students)
+			return tx.recordAudit(ctx, entries...)
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(purged)
+		if purged < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (r *mysqlStudentRepository) queryStudents(ctx context.Context, query string, args ...interface{}) ([]*domain.Student, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateStudentError(err)
+	}
+	defer rows.Close()
+
+	var students []*domain.Student
+	for rows.Next() {
+		student := &domain.Student{}
+		if err := scanStudent(rows, student); err != nil {
+			return nil, translateStudentError(err)
+		}
+		students = append(students, student)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateStudentError(err)
+	}
+	return students, nil
+}
+
+func (r *mysqlStudentRepository) ExistingEmails(ctx context.Context, emails []string) (map[string]uint, error) {
+	existing := make(map[string]uint)
+	if len(emails) == 0 {
+		return existing, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(emails)), ", ")
+	args := make([]interface{}, len(emails))
+	for i, email := range emails {
+		args[i] = email
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT id, email FROM students WHERE deleted_at IS NULL AND email IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, translateStudentError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, translateStudentError(err)
+		}
+		existing[strings.ToLower(email)] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateStudentError(err)
+	}
+	return existing, nil
+}
+
+func (r *mysqlStudentRepository) UpsertByEmail(ctx context.Context, students []*domain.Student) error {
+	if len(students) == 0 {
+		return nil
+	}
+	return r.inTx(ctx, func(tx *mysqlStudentRepository) error {
+		emails := make([]interface{}, len(students))
+		for i, student := range students {
+			emails[i] = student.Email
+		}
+		before, err := tx.liveStudentsByEmail(ctx, emails, true)
+		if err != nil {
+			return err
+		}
+		if err := tx.upsert(ctx, students); err != nil {
+			return err
+		}
+		after, err := tx.liveStudentsByEmail(ctx, emails, false)
+		if err != nil {
+			return err
+		}
+
+		entries := make([]*domain.StudentAuditEntry, 0, len(students))
+		for _, student := range students {
+			email := strings.ToLower(student.Email)
+			stored, ok := after[email]
+			if !ok {
+				continue
+			}
+			*student = *stored
+			action := domain.AuditUpdate
+			if before[email] == nil {
+				action = domain.AuditCreate
+			}
+			entries = append(entries, newAuditEntry(ctx, action, before[email], stored))
+		}
+		return tx.recordAudit(ctx, entries...)
+	})
+}
+
+// liveStudentsByEmail returns the live students using any of emails, keyed
+// by lower-cased email.
+func (r *mysqlStudentRepository) liveStudentsByEmail(ctx context.Context, emails []interface{}, forUpdate bool) (map[string]*domain.Student, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(emails)), ", ")
+	query := studentSelect + " WHERE deleted_at IS NULL AND email IN (" + placeholders + ")"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	students, err := r.queryStudents(ctx, query, emails...)
+	if err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]*domain.Student, len(students))
+	for _, student := range students {
+		byEmail[strings.ToLower(student.Email)] = student
+	}
+	return byEmail, nil
+}
+
+func (r *mysqlStudentRepository) upsert(ctx context.Context, students []*domain.Student) error {
+	now := time.Now()
+	for start := 0; start < len(students); start += insertChunkSize {
+		chunk := students[start:min(start+insertChunkSize, len(students))]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*7)
+		for i, student := range chunk {
+			placeholders[i] = "(?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, student.FirstName, student.LastName, student.Email, student.Age, student.Grade, now, now)
+		}
+		// email is UNIQUE, so a duplicate turns the insert into an update
+		// of the existing row; created_at is kept.
+		query := "INSERT INTO students (first_name, last_name, email, age, grade, created_at, updated_at) VALUES " +
+			strings.Join(placeholders, ", ") + ` AS new
+			ON DUPLICATE KEY UPDATE
+				first_name = new.first_name,
+				last_name = new.last_name,
+				age = new.age,
+				grade = new.grade,
+				updated_at = new.updated_at,
+				version = students.version + 1`
+
+		if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+			return translateStudentError(err)
+		}
+	}
+	return nil
+}
+
+// requireAffected reports NotFound when a statement matched no rows. The DSN
+// sets clientFoundRows so unchanged-but-matched rows still count.
+func requireAffected(result sql.Result, id uint) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return translateStudentError(err)
+	}
+	if n == 0 {
+		return domain.NewNotFoundError("student %d not found", id)
+	}
+	return nil
 }
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"strings"
+	"student-api/internal/domain"
+)
+
+// studentColumns maps sortable JSON field names to their database columns.
+var studentColumns = map[string]string{
+	"id":        "id",
+	"firstName": "first_name",
+	"lastName":  "last_name",
+	"email":     "email",
+	"age":       "age",
+	"grade":     "grade",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func buildStudentFilter(f domain.StudentFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if !f.IncludeDeleted {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if f.MinAge != nil {
+		conds = append(conds, "age >= ?")
+		args = append(args, *f.MinAge)
+	}
+	if f.MaxAge != nil {
+		conds = append(conds, "age <= ?")
+		args = append(args, *f.MaxAge)
+	}
+	if f.MinGrade != nil {
+		conds = append(conds, "grade >= ?")
+		args = append(args, *f.MinGrade)
+	}
+	if f.MaxGrade != nil {
+		conds = append(conds, "grade <= ?")
+		args = append(args, *f.MaxGrade)
+	}
+	if f.LastNamePrefix != "" {
+		conds = append(conds, "last_name LIKE ?")
+		args = append(args, likeEscaper.Replace(f.LastNamePrefix)+"%")
+	}
+	if f.EmailDomain != "" {
+		conds = append(conds, "email LIKE ?")
+		args = append(args, "%@"+likeEscaper.Replace(f.EmailDomain))
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// buildStudentOrderBy always ends with id so that ordering is deterministic
+// across pages. reverse flips every direction for backwards keyset paging.
+func buildStudentOrderBy(sort []domain.SortField, reverse bool) string {
+	parts := make([]string, 0, len(sort)+1)
+	idSorted := false
+	for _, s := range sort {
+		column, ok := studentColumns[s.Field]
+		if !ok {
+			continue
+		}
+		parts = append(parts, column+direction(s.Desc, reverse))
+		if column == "id" {
+			idSorted = true
+			break
+		}
+	}
+	if !idSorted {
+		parts = append(parts, "id"+direction(false, reverse))
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+func direction(desc, reverse bool) string {
+	if desc != reverse {
+		return " DESC"
+	}
+	return " ASC"
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"student-api/internal/domain"
+)
+
+// ExecuteBatch validates every operation up front, then runs them in order.
+// Consecutive creates are written with multi-row INSERTs; if one fails
+// because of a row's data, its rows are retried one by one so the failing
+// item can be identified.
+func (s *studentService) ExecuteBatch(ctx context.Context, mode domain.BatchMode, ops []domain.BatchOperation) ([]domain.BatchResult, error) {
+	results := make([]domain.BatchResult, len(ops))
+	failed := false
+	for i, op := range ops {
+		results[i] = domain.BatchResult{Op: op.Op, ID: op.ID, Err: op.Err}
+		if op.Err == nil && (op.Op == domain.BatchCreate || op.Op == domain.BatchUpdate) {
+			results[i].Err = validateStudent(op.Student)
+		}
+		failed = failed || results[i].Err != nil
+	}
+
+	if mode != domain.BatchAtomic {
+		runBatch(ctx, s.repo, ops, results, false)
+		return results, nil
+	}
+
+	if failed {
+		domain.AbortBatch(results)
+		return results, nil
+	}
+	err := s.repo.InTransaction(ctx, func(repo domain.StudentRepository) error {
+		return runBatch(ctx, repo, ops, results, true)
+	})
+	if err != nil {
+		if !domain.IsItemError(err) {
+			return nil, err
+		}
+		domain.AbortBatch(results)
+	}
+	return results, nil
+}
+
+// runBatch executes the operations whose result has no error yet. With
+// stopOnError it returns the first failure, leaving later operations
+// unexecuted, so the caller can roll back.
+func runBatch(ctx context.Context, repo domain.StudentRepository, ops []domain.BatchOperation, results []domain.BatchResult, stopOnError bool) error {
+	for i := 0; i < len(ops); i++ {
+		if results[i].Err != nil {
+			continue
+		}
+
+		op := ops[i]
+		var err error
+		switch op.Op {
+		case domain.BatchCreate:
+			end := i + 1
+			for end < len(ops) && ops[end].Op == domain.BatchCreate && results[end].Err == nil {
+				end++
+			}
+			err = createRun(ctx, repo, ops[i:end], results[i:end], stopOnError)
+			i = end - 1
+		case domain.BatchUpdate:
+			op.Student.ID = op.ID
+			op.Student.Version = op.Version
+			if err = repo.Update(ctx, op.Student); err == nil {
+				results[i].Student = op.Student
+			}
+			results[i].Err = err
+		case domain.BatchDelete:
+			err = repo.Delete(ctx, op.ID)
+			results[i].Err = err
+		}
+		if err != nil && stopOnError {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRun inserts a run of consecutive creates, falling back to single
+// inserts for the rows a failed multi-row INSERT did not store.
+func createRun(ctx context.Context, repo domain.StudentRepository, ops []domain.BatchOperation, results []domain.BatchResult, stopOnError bool) error {
+	students := make([]*domain.Student, len(ops))
+	for i, op := range ops {
+		students[i] = op.Student
+	}
+
+	err := repo.CreateMany(ctx, students)
+	if err != nil && !domain.IsItemError(err) {
+		for i := range results {
+			if students[i].ID == 0 {
+				results[i].Err = err
+			}
+		}
+		return err
+	}
+
+	for i, student := range students {
+		if err != nil && student.ID == 0 {
+			if createErr := repo.Create(ctx, student); createErr != nil {
+				results[i].Err = createErr
+				if stopOnError {
+					return createErr
+				}
+				continue
+			}
+		}
+		results[i].ID = student.ID
+		results[i].Student = student
+	}
+	return nil
+}
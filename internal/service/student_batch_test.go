@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"student-api/internal/domain"
+	"student-api/internal/repository"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// fakeDB is a database/sql driver that stores only the ids of inserted
+// students, so tests can check which ones a transaction really committed.
+// Inserting a student with failEmail fails with a duplicate entry error.
+type fakeDB struct {
+	failEmail string
+
+	mu        sync.Mutex
+	nextID    int64
+	pending   []int64
+	committed map[int64]bool
+}
+
+func newFakeDB(failEmail string) *fakeDB {
+	return &fakeDB{failEmail: failEmail, nextID: 1, committed: make(map[int64]bool)}
+}
+
+func (d *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: d}, nil }
+func (d *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, id := range c.db.pending {
+		c.db.committed[id] = true
+	}
+	c.db.pending = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.pending = nil
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if !strings.HasPrefix(strings.TrimSpace(query), "INSERT INTO students ") {
+		return driver.RowsAffected(1), nil
+	}
+	for _, arg := range args {
+		if arg.Value == c.db.failEmail {
+			return nil, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+		}
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	rows := int64(len(args) / 7)
+	first := c.db.nextID
+	for id := first; id < first+rows; id++ {
+		c.db.pending = append(c.db.pending, id)
+	}
+	c.db.nextID += rows
+	return fakeResult{lastInsertID: first, rowsAffected: rows}, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "auto_increment_increment") {
+		return &fakeRows{values: []driver.Value{int64(1)}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", query)
+}
+
+type fakeResult struct {
+	lastInsertID, rowsAffected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// fakeRows returns a single row.
+type fakeRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *fakeRows) Columns() []string { return make([]string, len(r.values)) }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestExecuteBatchBestEffortRollsBackFailedChunk(t *testing.T) {
+	// The failing create is in the second multi-row INSERT, so the
+	// transaction that stored the first one rolls back.
+	const total, failing = 600, 550
+	fake := newFakeDB(fmt.Sprintf("student%d@example.com", failing))
+	db := sql.OpenDB(fake)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	ops := make([]domain.BatchOperation, total)
+	for i := range ops {
+		ops[i] = domain.BatchOperation{Op: domain.BatchCreate, Student: &domain.Student{
+			FirstName: "Ada",
+			LastName:  "Lovelace",
+			Email:     fmt.Sprintf("student%d@example.com", i),
+			Age:       20,
+			Grade:     3.5,
+		}}
+	}
+
+	service := NewStudentService(repository.NewMySQLStudentRepository(db))
+	results, err := service.ExecuteBatch(context.Background(), domain.BatchBestEffort, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, result := range results {
+		if i == failing {
+			if !domain.IsItemError(result.Err) {
+				t.Errorf("result %d error = %v, want a conflict", i, result.Err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("result %d error = %v, want created", i, result.Err)
+			continue
+		}
+		if !fake.committed[int64(result.ID)] {
+			t.Errorf("result %d reported as created with id %d, which was rolled back", i, result.ID)
+		}
+	}
+	if got := len(fake.committed); got != total-1 {
+		t.Errorf("%d students committed, want %d", got, total-1)
+	}
+}
+
+// memoryRepository keeps students in a map, refusing duplicate emails with
+// a conflict. CreateMany stores all of its students or none.
+type memoryRepository struct {
+	domain.StudentRepository
+	students map[uint]domain.Student
+	nextID   uint
+}
+
+func newMemoryRepository(emails ...string) *memoryRepository {
+	r := &memoryRepository{students: make(map[uint]domain.Student), nextID: 1}
+	for _, email := range emails {
+		if err := r.Create(context.Background(), &domain.Student{Email: email}); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+func (r *memoryRepository) emailTaken(email string, except uint) bool {
+	for id, s := range r.students {
+		if id != except && strings.EqualFold(s.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryRepository) Create(ctx context.Context, student *domain.Student) error {
+	if r.emailTaken(student.Email, 0) {
+		return domain.NewConflictError(nil, "a student with this email already exists")
+	}
+	student.ID = r.nextID
+	student.Version = 1
+	r.nextID++
+	r.students[student.ID] = *student
+	return nil
+}
+
+func (r *memoryRepository) CreateMany(ctx context.Context, students []*domain.Student) error {
+	err := r.InTransaction(ctx, func(repo domain.StudentRepository) error {
+		for _, student := range students {
+			if err := repo.Create(ctx, student); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, student := range students {
+			student.ID = 0
+		}
+	}
+	return err
+}
+
+func (r *memoryRepository) Update(ctx context.Context, student *domain.Student) error {
+	stored, ok := r.students[student.ID]
+	if !ok {
+		return domain.NewNotFoundError("student %d not found", student.ID)
+	}
+	if student.Version != 0 && student.Version != stored.Version {
+		return domain.NewPreconditionFailedError("student %d has changed", student.ID)
+	}
+	if r.emailTaken(student.Email, student.ID) {
+		return domain.NewConflictError(nil, "a student with this email already exists")
+	}
+	student.Version = stored.Version + 1
+	r.students[student.ID] = *student
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id uint) error {
+	if _, ok := r.students[id]; !ok {
+		return domain.NewNotFoundError("student %d not found", id)
+	}
+	delete(r.students, id)
+	return nil
+}
+
+func (r *memoryRepository) InTransaction(ctx context.Context, fn func(repo domain.StudentRepository) error) error {
+	saved := make(map[uint]domain.Student, len(r.students))
+	for id, s := range r.students {
+		saved[id] = s
+	}
+	if err := fn(r); err != nil {
+		r.students = saved
+		return err
+	}
+	return nil
+}
+
+func batchStudent(email string) *domain.Student {
+	return &domain.Student{FirstName: "Ada", LastName: "Lovelace", Email: email, Age: 20, Grade: 3.5}
+}
+
+func TestExecuteBatchBestEffort(t *testing.T) {
+	repo := newMemoryRepository("taken@example.com", "old@example.com")
+	ops := []domain.BatchOperation{
+		{Op: domain.BatchCreate, Student: batchStudent("new@example.com")},
+		{Op: domain.BatchCreate, Student: batchStudent("taken@example.com")},
+		{Op: domain.BatchCreate, Student: batchStudent("other@example.com")},
+		{Op: domain.BatchCreate, Student: batchStudent("not-an-email")},
+		{Op: domain.BatchUpdate, ID: 2, Student: batchStudent("renamed@example.com")},
+		{Op: domain.BatchUpdate, ID: 2, Version: 1, Student: batchStudent("stale@example.com")},
+		{Op: domain.BatchDelete, ID: 99},
+		{Op: domain.BatchDelete, ID: 1},
+	}
+
+	results, err := NewStudentService(repo).ExecuteBatch(context.Background(), domain.BatchBestEffort, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantErrs := []error{nil, domain.ErrConflict, nil, domain.ErrValidation, nil, domain.ErrPreconditionFailed, domain.ErrNotFound, nil}
+	for i, want := range wantErrs {
+		if got := results[i].Err; (want == nil && got != nil) || (want != nil && !errors.Is(got, want)) {
+			t.Errorf("result %d error = %v, want %v", i, got, want)
+		}
+	}
+	for _, i := range []int{0, 2} {
+		if results[i].ID == 0 || repo.students[results[i].ID].Email != ops[i].Student.Email {
+			t.Errorf("result %d = id %d, not stored", i, results[i].ID)
+		}
+	}
+	if results[1].ID != 0 {
+		t.Errorf("failed create reported id %d", results[1].ID)
+	}
+	if got := repo.students[2]; got.Email != "renamed@example.com" || got.Version != 2 {
+		t.Errorf("student 2 = %+v, want renamed at version 2", got)
+	}
+	if _, ok := repo.students[1]; ok {
+		t.Error("student 1 was not deleted")
+	}
+}
+
+func TestExecuteBatchAtomicRollsBack(t *testing.T) {
+	repo := newMemoryRepository("old@example.com")
+	ops := []domain.BatchOperation{
+		{Op: domain.BatchCreate, Student: batchStudent("new@example.com")},
+		{Op: domain.BatchUpdate, ID: 1, Student: batchStudent("renamed@example.com")},
+		{Op: domain.BatchDelete, ID: 99},
+		{Op: domain.BatchCreate, Student: batchStudent("later@example.com")},
+	}
+
+	results, err := NewStudentService(repo).ExecuteBatch(context.Background(), domain.BatchAtomic, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		want := domain.ErrBatchAborted
+		if i == 2 {
+			want = domain.ErrNotFound
+		}
+		if !errors.Is(result.Err, want) {
+			t.Errorf("result %d error = %v, want %v", i, result.Err, want)
+		}
+	}
+	if results[0].ID != 0 {
+		t.Errorf("rolled back create reported id %d", results[0].ID)
+	}
+	if len(repo.students) != 1 || repo.students[1].Email != "old@example.com" {
+		t.Errorf("students = %+v, want only the original", repo.students)
+	}
+}
+
+func TestExecuteBatchAtomicValidatesFirst(t *testing.T) {
+	repo := newMemoryRepository()
+	ops := []domain.BatchOperation{
+		{Op: domain.BatchCreate, Student: batchStudent("new@example.com")},
+		{Op: domain.BatchCreate, Student: batchStudent("not-an-email")},
+	}
+
+	results, err := NewStudentService(repo).ExecuteBatch(context.Background(), domain.BatchAtomic, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, domain.ErrBatchAborted) || !errors.Is(results[1].Err, domain.ErrValidation) {
+		t.Errorf("errors = %v, %v; want aborted, validation", results[0].Err, results[1].Err)
+	}
+	if len(repo.students) != 0 {
+		t.Errorf("%d students stored, want none", len(repo.students))
+	}
+}
+
+func TestExecuteBatchAtomic(t *testing.T) {
+	repo := newMemoryRepository("old@example.com")
+	ops := []domain.BatchOperation{
+		{Op: domain.BatchCreate, Student: batchStudent("a@example.com")},
+		{Op: domain.BatchCreate, Student: batchStudent("b@example.com")},
+		{Op: domain.BatchDelete, ID: 1},
+	}
+
+	results, err := NewStudentService(repo).ExecuteBatch(context.Background(), domain.BatchAtomic, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Errorf("result %d error = %v", i, result.Err)
+		}
+	}
+	if len(repo.students) != 2 || results[0].ID == results[1].ID {
+		t.Errorf("students = %+v, results = %+v", repo.students, results)
+	}
+}
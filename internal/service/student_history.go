@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"student-api/internal/domain"
+	"time"
+)
+
+// GetStudentAsOf starts from the stored record and undoes every audited
+// write made after asOf, newest first. Reaching the student's create entry
+// means it did not exist yet.
+func (s *studentService) GetStudentAsOf(ctx context.Context, id uint, asOf time.Time, includeDeleted bool) (*domain.Student, error) {
+	student, err := s.repo.GetByID(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.AuditSince(ctx, id, asOf)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].Action == domain.AuditCreate {
+			return nil, domain.NewNotFoundError("student %d did not exist at %s", id, asOf.Format(time.RFC3339))
+		}
+		if err := entries[i].Revert(student); err != nil {
+			return nil, err
+		}
+	}
+	if student.CreatedAt.After(asOf) {
+		return nil, domain.NewNotFoundError("student %d did not exist at %s", id, asOf.Format(time.RFC3339))
+	}
+	if student.DeletedAt != nil && !includeDeleted {
+		return nil, domain.NewNotFoundError("student %d not found", id)
+	}
+	return student, nil
+}
+
+// GetStudentHistory returns the audit trail, which is kept after the
+// student is purged; an empty trail of an unknown student is NotFound.
+func (s *studentService) GetStudentHistory(ctx context.Context, id uint, query domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = domain.DefaultStudentPageSize
+	}
+	if query.Limit > domain.MaxStudentPageSize {
+		query.Limit = domain.MaxStudentPageSize
+	}
+	page, err := s.repo.History(ctx, id, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Entries) == 0 && query.BeforeID == 0 {
+		if _, err := s.repo.GetByID(ctx, id, true); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
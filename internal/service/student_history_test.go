@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"student-api/internal/domain"
+	"testing"
+	"time"
+)
+
+// auditedRepository holds one student and its audit trail, with the
+// changes round-tripped through JSON as they are when read from MySQL.
+type auditedRepository struct {
+	domain.StudentRepository
+	student *domain.Student
+	entries []domain.StudentAuditEntry
+}
+
+// write applies change to the student at at and records it as action.
+func (r *auditedRepository) write(t *testing.T, action domain.AuditAction, at time.Time, change func(*domain.Student)) {
+	t.Helper()
+	before := r.student
+	after := &domain.Student{ID: 1, CreatedAt: at}
+	if before != nil {
+		copied := *before
+		after = &copied
+	}
+	change(after)
+	after.Version++
+	after.UpdatedAt = at
+
+	document, err := json.Marshal(domain.AuditChanges(before, after))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes map[string]domain.FieldChange
+	if err := json.Unmarshal(document, &changes); err != nil {
+		t.Fatal(err)
+	}
+	r.entries = append(r.entries, domain.StudentAuditEntry{
+		ID: uint64(len(r.entries) + 1), StudentID: 1, Action: action, Version: after.Version, Changes: changes, At: at,
+	})
+	r.student = after
+}
+
+func (r *auditedRepository) GetByID(ctx context.Context, id uint, includeDeleted bool) (*domain.Student, error) {
+	if r.student == nil || (r.student.DeletedAt != nil && !includeDeleted) {
+		return nil, domain.NewNotFoundError("student %d not found", id)
+	}
+	copied := *r.student
+	return &copied, nil
+}
+
+func (r *auditedRepository) AuditSince(ctx context.Context, id uint, since time.Time) ([]domain.StudentAuditEntry, error) {
+	var entries []domain.StudentAuditEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].At.After(since) {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+func TestGetStudentAsOf(t *testing.T) {
+	base := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	repo := &auditedRepository{}
+	repo.write(t, domain.AuditCreate, at(10), func(s *domain.Student) {
+		s.FirstName, s.LastName, s.Email, s.Age, s.Grade = "Ada", "Lovelace", "ada@example.com", 20, 85.5
+	})
+	repo.write(t, domain.AuditUpdate, at(20), func(s *domain.Student) { s.Grade, s.Age = 91, 21 })
+	repo.write(t, domain.AuditDelete, at(30), func(s *domain.Student) { deletedAt := at(30); s.DeletedAt = &deletedAt })
+	repo.write(t, domain.AuditRestore, at(40), func(s *domain.Student) { s.DeletedAt = nil })
+	repo.write(t, domain.AuditUpdate, at(50), func(s *domain.Student) { s.Email = "ada.lovelace@example.com" })
+
+	type state struct {
+		email   string
+		age     int
+		grade   float64
+		version uint64
+		deleted bool
+	}
+	tests := []struct {
+		name           string
+		asOf           time.Time
+		includeDeleted bool
+		want           *state // nil means not found
+	}{
+		{"before create", at(9), false, nil},
+		{"at create", at(10), false, &state{"ada@example.com", 20, 85.5, 1, false}},
+		{"before update", at(19), false, &state{"ada@example.com", 20, 85.5, 1, false}},
+		{"at update", at(20), false, &state{"ada@example.com", 21, 91, 2, false}},
+		{"while deleted", at(35), false, nil},
+		{"while deleted with includeDeleted", at(35), true, &state{"ada@example.com", 21, 91, 3, true}},
+		{"after restore", at(40), false, &state{"ada@example.com", 21, 91, 4, false}},
+		{"now", at(60), false, &state{"ada.lovelace@example.com", 21, 91, 5, false}},
+	}
+	service := NewStudentService(repo)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			student, err := service.GetStudentAsOf(context.Background(), 1, tt.asOf, tt.includeDeleted)
+			if tt.want == nil {
+				if !errors.Is(err, domain.ErrNotFound) {
+					t.Errorf("GetStudentAsOf = %+v, %v, want not found", student, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := state{student.Email, student.Age, student.Grade, student.Version, student.DeletedAt != nil}
+			if got != *tt.want {
+				t.Errorf("state = %+v, want %+v", got, *tt.want)
+			}
+			if student.FirstName != "Ada" || !student.CreatedAt.Equal(at(10)) {
+				t.Errorf("student = %+v", student)
+			}
+			if student.UpdatedAt.After(tt.asOf) {
+				t.Errorf("updatedAt %v is after asOf %v", student.UpdatedAt, tt.asOf)
+			}
+		})
+	}
+}
+
+func TestGetStudentAsOfPurged(t *testing.T) {
+	service := NewStudentService(&auditedRepository{})
+	if _, err := service.GetStudentAsOf(context.Background(), 1, time.Now(), true); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("purged student: %v, want not found", err)
+	}
+}
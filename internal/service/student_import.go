@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"student-api/internal/domain"
+)
+
+// importChunkSize is the number of valid rows looked up and upserted
+// together.
+const importChunkSize = 500
+
+// ImportStudents streams rows, rejecting those that fail conversion or
+// validation or repeat an email seen earlier in the file, and upserts the
+// rest by email in chunks.
+func (s *studentService) ImportStudents(ctx context.Context, rows domain.StudentRows, dryRun bool) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{DryRun: dryRun, Errors: []domain.ImportRowError{}}
+	seen := make(map[string]int)
+	chunk := make([]*domain.ImportRow, 0, importChunkSize)
+
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.Rows++
+
+		if len(row.Fields) > 0 {
+			report.RejectRow(row.Row, row.Fields)
+			continue
+		}
+		if err := validateStudent(row.Student); err != nil {
+			report.RejectRow(row.Row, domain.FieldErrors(err))
+			continue
+		}
+		email := strings.ToLower(row.Student.Email)
+		if first, ok := seen[email]; ok {
+			report.RejectRow(row.Row, []domain.FieldError{{Field: "email", Message: fmt.Sprintf("duplicates row %d", first)}})
+			continue
+		}
+		seen[email] = row.Row
+
+		chunk = append(chunk, row)
+		if len(chunk) == importChunkSize {
+			if err := s.importChunk(ctx, chunk, report, dryRun); err != nil {
+				return nil, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+
+	if err := s.importChunk(ctx, chunk, report, dryRun); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importChunk upserts valid rows and counts them as created or updated.
+func (s *studentService) importChunk(ctx context.Context, chunk []*domain.ImportRow, report *domain.ImportReport, dryRun bool) error {
+	if len(chunk) == 0 {
+		return nil
+	}
+
+	emails := make([]string, len(chunk))
+	for i, row := range chunk {
+		emails[i] = row.Student.Email
+	}
+	existing, err := s.repo.ExistingEmails(ctx, emails)
+	if err != nil {
+		return err
+	}
+
+	var rejected map[int]bool
+	if !dryRun {
+		if rejected, err = s.upsertRows(ctx, chunk, report); err != nil {
+			return err
+		}
+	}
+
+	for i, row := range chunk {
+		if rejected[i] {
+			continue
+		}
+		if _, ok := existing[strings.ToLower(row.Student.Email)]; ok {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	return nil
+}
+
+// upsertRows writes the chunk with one statement. If the database refuses
+// it because of a row's data, the rows are retried one at a time so only the
+// offending ones are rejected; their indexes are returned.
+func (s *studentService) upsertRows(ctx context.Context, chunk []*domain.ImportRow, report *domain.ImportReport) (map[int]bool, error) {
+	students := make([]*domain.Student, len(chunk))
+	for i, row := range chunk {
+		students[i] = row.Student
+	}
+	err := s.repo.UpsertByEmail(ctx, students)
+	if err == nil || !domain.IsItemError(err) {
+		return nil, err
+	}
+
+	rejected := make(map[int]bool)
+	for i, row := range chunk {
+		err := s.repo.UpsertByEmail(ctx, []*domain.Student{row.Student})
+		if err != nil && !domain.IsItemError(err) {
+			return nil, err
+		}
+		if err != nil {
+			report.RejectRowError(row.Row, err)
+			rejected[i] = true
+		}
+	}
+	return rejected, nil
+}
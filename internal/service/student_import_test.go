@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"student-api/internal/domain"
+	"testing"
+)
+
+// importRepository stores students by email. Upserting a batch holding
+// badEmail fails with a data error, as MySQL refuses the whole statement.
+type importRepository struct {
+	domain.StudentRepository
+	badEmail string
+	stored   map[string]bool
+	upserts  int
+}
+
+func (r *importRepository) ExistingEmails(ctx context.Context, emails []string) (map[string]uint, error) {
+	existing := make(map[string]uint)
+	for _, email := range emails {
+		if r.stored[strings.ToLower(email)] {
+			existing[strings.ToLower(email)] = 1
+		}
+	}
+	return existing, nil
+}
+
+func (r *importRepository) UpsertByEmail(ctx context.Context, students []*domain.Student) error {
+	r.upserts++
+	for _, s := range students {
+		if s.Email == r.badEmail {
+			return domain.NewValidationError(nil, "student data is invalid")
+		}
+	}
+	for _, s := range students {
+		r.stored[strings.ToLower(s.Email)] = true
+	}
+	return nil
+}
+
+// sliceRows yields rows in order.
+type sliceRows []*domain.ImportRow
+
+func (r *sliceRows) Next() (*domain.ImportRow, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	row := (*r)[0]
+	*r = (*r)[1:]
+	return row, nil
+}
+
+func importRow(row int, email string) *domain.ImportRow {
+	return &domain.ImportRow{Row: row, Student: &domain.Student{
+		FirstName: "Ada", LastName: "Lovelace", Email: email, Age: 20, Grade: 90,
+	}}
+}
+
+func TestImportStudents(t *testing.T) {
+	repo := &importRepository{badEmail: "bad@example.com", stored: map[string]bool{"old@example.com": true}}
+	svc := NewStudentService(repo)
+	rows := sliceRows{
+		importRow(2, "new@example.com"),
+		importRow(3, "OLD@example.com"),
+		importRow(4, "bad@example.com"),
+		importRow(5, "New@Example.com"),
+		{Row: 6, Fields: []domain.FieldError{{Field: "age", Message: "must be an integer"}}},
+		importRow(7, "not-an-email"),
+	}
+
+	report, err := svc.ImportStudents(context.Background(), &rows, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 6 || report.Created != 1 || report.Updated != 1 || report.Rejected != 4 {
+		t.Errorf("report = rows %d, created %d, updated %d, rejected %d; want 6, 1, 1, 4",
+			report.Rows, report.Created, report.Updated, report.Rejected)
+	}
+	rejected := make(map[int]string)
+	for _, e := range report.Errors {
+		rejected[e.Row] = e.Message
+	}
+	if want := "duplicates row 2"; rejected[5] != want {
+		t.Errorf("row 5 error = %q, want %q", rejected[5], want)
+	}
+	if want := "student data is invalid"; rejected[4] != want {
+		t.Errorf("row 4 error = %q, want %q", rejected[4], want)
+	}
+	for _, row := range []int{6, 7} {
+		if _, ok := rejected[row]; !ok {
+			t.Errorf("row %d was not rejected", row)
+		}
+	}
+	if want := map[string]bool{"old@example.com": true, "new@example.com": true}; !reflect.DeepEqual(repo.stored, want) {
+		t.Errorf("stored = %v, want %v", repo.stored, want)
+	}
+	// One batch, then one retry per row in it.
+	if repo.upserts != 4 {
+		t.Errorf("%d upserts, want 4", repo.upserts)
+	}
+}
+
+func TestImportStudentsDryRun(t *testing.T) {
+	repo := &importRepository{stored: map[string]bool{"old@example.com": true}}
+	rows := sliceRows{importRow(2, "new@example.com"), importRow(3, "old@example.com")}
+
+	report, err := NewStudentService(repo).ImportStudents(context.Background(), &rows, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || report.Created != 1 || report.Updated != 1 || report.Rejected != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if repo.upserts != 0 {
+		t.Errorf("dry run upserted %d times", repo.upserts)
+	}
+}
+
+func TestImportStudentsStoreError(t *testing.T) {
+	unavailable := domain.NewUnavailableError(errors.New("connection refused"), "database unavailable")
+	repo := &failingImportRepository{err: unavailable}
+	rows := sliceRows{importRow(2, "new@example.com")}
+
+	if _, err := NewStudentService(repo).ImportStudents(context.Background(), &rows, false); !errors.Is(err, unavailable) {
+		t.Errorf("error = %v, want %v", err, unavailable)
+	}
+}
+
+// failingImportRepository fails every upsert with err.
+type failingImportRepository struct {
+	domain.StudentRepository
+	err error
+}
+
+func (r *failingImportRepository) ExistingEmails(context.Context, []string) (map[string]uint, error) {
+	return nil, nil
+}
+
+func (r *failingImportRepository) UpsertByEmail(context.Context, []*domain.Student) error {
+	return r.err
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+	"time"
+)
+
+// Policy maps roles to the permissions they grant.
+type Policy struct {
+	roles map[string]map[domain.Permission]bool
+}
+
+// NewPolicy builds a policy from role names to permission names, rejecting
+// unknown permissions so a typo in the configuration fails at startup.
+func NewPolicy(rolePermissions map[string][]string) (*Policy, error) {
+	known := map[domain.Permission]bool{domain.PermissionAll: true}
+	for _, permission := range domain.Permissions {
+		known[permission] = true
+	}
+
+	p := &Policy{roles: make(map[string]map[domain.Permission]bool, len(rolePermissions))}
+	for role, names := range rolePermissions {
+		granted := make(map[domain.Permission]bool, len(names))
+		for _, name := range names {
+			permission := domain.Permission(name)
+			if !known[permission] {
+				return nil, fmt.Errorf("role %q: unknown permission %q", role, name)
+			}
+			granted[permission] = true
+		}
+		p.roles[role] = granted
+	}
+	return p, nil
+}
+
+// Allows reports whether principal holds permission through one of its
+// roles or directly as a token scope.
+func (p *Policy) Allows(principal *domain.Principal, permission domain.Permission) bool {
+	for _, role := range principal.Roles {
+		if granted := p.roles[role]; granted[permission] || granted[domain.PermissionAll] {
+			return true
+		}
+	}
+	for _, scope := range principal.Scopes {
+		if domain.Permission(scope) == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) Authorize(ctx context.Context, permissions ...domain.Permission) error {
+	principal := logging.GetPrincipalFromContext(ctx)
+	for _, permission := range permissions {
+		if principal == nil || !p.Allows(principal, permission) {
+			return domain.NewForbiddenError(permission)
+		}
+	}
+	return nil
+}
+
+type authorizedStudentService struct {
+	next   domain.StudentService
+	policy domain.Authorizer
+}
+
+// NewAuthorizedStudentService checks the permissions of the request's
+// principal before each call to next, failing with ErrForbidden naming the
+// first missing permission.
+func NewAuthorizedStudentService(next domain.StudentService, policy domain.Authorizer) domain.StudentService {
+	return &authorizedStudentService{next: next, policy: policy}
+}
+
+func (s *authorizedStudentService) authorize(ctx context.Context, permissions ...domain.Permission) error {
+	return s.policy.Authorize(ctx, permissions...)
+}
+
+// readPermissions adds PermissionReadDeleted when soft-deleted students are
+// requested.
+func readPermissions(includeDeleted bool) []domain.Permission {
+	if includeDeleted {
+		return []domain.Permission{domain.PermissionRead, domain.PermissionReadDeleted}
+	}
+	return []domain.Permission{domain.PermissionRead}
+}
+
+func (s *authorizedStudentService) CreateStudent(ctx context.Context, student *domain.Student) error {
+	if err := s.authorize(ctx, domain.PermissionCreate); err != nil {
+		return err
+	}
+	return s.next.CreateStudent(ctx, student)
+}
+
+func (s *authorizedStudentService) GetStudent(ctx context.Context, id uint, includeDeleted bool) (*domain.Student, error) {
+	if err := s.authorize(ctx, readPermissions(includeDeleted)...); err != nil {
+		return nil, err
+	}
+	return s.next.GetStudent(ctx, id, includeDeleted)
+}
+
+func (s *authorizedStudentService) GetAllStudents(ctx context.Context, query domain.StudentQuery) (*domain.StudentPage, error) {
+	if err := s.authorize(ctx, readPermissions(query.Filter.IncludeDeleted)...); err != nil {
+		return nil, err
+	}
+	return s.next.GetAllStudents(ctx, query)
+}
+
+func (s *authorizedStudentService) ExportStudents(ctx context.Context, query domain.StudentQuery, fn func(*domain.Student) error) error {
+	if err := s.authorize(ctx, readPermissions(query.Filter.IncludeDeleted)...); err != nil {
+		return err
+	}
+	return s.next.ExportStudents(ctx, query, fn)
+}
+
+func (s *authorizedStudentService) UpdateStudent(ctx context.Context, student *domain.Student) error {
+	if err := s.authorize(ctx, domain.PermissionUpdate); err != nil {
+		return err
+	}
+	return s.next.UpdateStudent(ctx, student)
+}
+
+func (s *authorizedStudentService) PatchStudent(ctx context.Context, id uint, ifVersion uint64, patch domain.StudentPatch) (*domain.Student, error) {
+	if err := s.authorize(ctx, domain.PermissionUpdate); err != nil {
+		return nil, err
+	}
+	return s.next.PatchStudent(ctx, id, ifVersion, patch)
+}
+
+func (s *authorizedStudentService) DeleteStudent(ctx context.Context, id uint) error {
+	if err := s.authorize(ctx, domain.PermissionDelete); err != nil {
+		return err
+	}
+	return s.next.DeleteStudent(ctx, id)
+}
+
+// GetStudentAsOf treats a student deleted or purged since asOf like the
+// history does: without PermissionReadDeleted it is NotFound.
+func (s *authorizedStudentService) GetStudentAsOf(ctx context.Context, id uint, asOf time.Time, includeDeleted bool) (*domain.Student, error) {
+	if err := s.authorize(ctx, readPermissions(includeDeleted)...); err != nil {
+		return nil, err
+	}
+	if err := s.requireLiveUnlessReadDeleted(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.next.GetStudentAsOf(ctx, id, asOf, includeDeleted)
+}
+
+// GetStudentHistory needs PermissionReadDeleted for the trail of a deleted
+// or purged student; without it such a student is NotFound, as it is on
+// the other reads.
+func (s *authorizedStudentService) GetStudentHistory(ctx context.Context, id uint, query domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if err := s.authorize(ctx, domain.PermissionRead); err != nil {
+		return nil, err
+	}
+	if err := s.requireLiveUnlessReadDeleted(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.next.GetStudentHistory(ctx, id, query)
+}
+
+// requireLiveUnlessReadDeleted fails with NotFound if the caller lacks
+// PermissionReadDeleted and the student is deleted or purged.
+func (s *authorizedStudentService) requireLiveUnlessReadDeleted(ctx context.Context, id uint) error {
+	if s.authorize(ctx, domain.PermissionReadDeleted) == nil {
+		return nil
+	}
+	_, err := s.next.GetStudent(ctx, id, false)
+	return err
+}
+
+func (s *authorizedStudentService) RestoreStudent(ctx context.Context, id uint) (*domain.Student, error) {
+	if err := s.authorize(ctx, domain.PermissionDelete); err != nil {
+		return nil, err
+	}
+	return s.next.RestoreStudent(ctx, id)
+}
+
+func (s *authorizedStudentService) PurgeDeletedStudents(ctx context.Context, retention time.Duration) (int64, error) {
+	if err := s.authorize(ctx, domain.PermissionPurge); err != nil {
+		return 0, err
+	}
+	return s.next.PurgeDeletedStudents(ctx, retention)
+}
+
+// ExecuteBatch requires the permissions of every operation in the batch up
+// front, so a batch is never partly refused.
+func (s *authorizedStudentService) ExecuteBatch(ctx context.Context, mode domain.BatchMode, ops []domain.BatchOperation) ([]domain.BatchResult, error) {
+	needed := make(map[domain.BatchOp]bool)
+	for _, op := range ops {
+		needed[op.Op] = true
+	}
+	var permissions []domain.Permission
+	if needed[domain.BatchCreate] {
+		permissions = append(permissions, domain.PermissionCreate)
+	}
+	if needed[domain.BatchUpdate] {
+		permissions = append(permissions, domain.PermissionUpdate)
+	}
+	if needed[domain.BatchDelete] {
+		permissions = append(permissions, domain.PermissionDelete)
+	}
+	if err := s.authorize(ctx, permissions...); err != nil {
+		return nil, err
+	}
+	return s.next.ExecuteBatch(ctx, mode, ops)
+}
+
+// ImportStudents requires both create and update, as an import upserts.
+func (s *authorizedStudentService) ImportStudents(ctx context.Context, rows domain.StudentRows, dryRun bool) (*domain.ImportReport, error) {
+	if err := s.authorize(ctx, domain.PermissionCreate, domain.PermissionUpdate); err != nil {
+		return nil, err
+	}
+	return s.next.ImportStudents(ctx, rows, dryRun)
+}
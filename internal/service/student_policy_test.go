@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"student-api/internal/domain"
+	"student-api/internal/logging"
+	"testing"
+	"time"
+)
+
+// historyService knows one live student and one deleted one; any other
+// method panics on the nil embedded service.
+type historyService struct {
+	domain.StudentService
+}
+
+const (
+	liveStudentID    = 1
+	deletedStudentID = 2
+)
+
+func (historyService) GetStudent(ctx context.Context, id uint, includeDeleted bool) (*domain.Student, error) {
+	if id == liveStudentID || (id == deletedStudentID && includeDeleted) {
+		return &domain.Student{ID: id}, nil
+	}
+	return nil, domain.NewNotFoundError("student %d not found", id)
+}
+
+func (historyService) GetStudentAsOf(ctx context.Context, id uint, asOf time.Time, includeDeleted bool) (*domain.Student, error) {
+	return &domain.Student{ID: id}, nil
+}
+
+func (historyService) GetStudentHistory(ctx context.Context, id uint, query domain.HistoryQuery) (*domain.HistoryPage, error) {
+	return &domain.HistoryPage{}, nil
+}
+
+func TestPastReadsOfDeletedStudentNeedReadDeleted(t *testing.T) {
+	policy, err := NewPolicy(map[string][]string{
+		"teacher": {string(domain.PermissionRead)},
+		"admin":   {string(domain.PermissionRead), string(domain.PermissionReadDeleted)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewAuthorizedStudentService(historyService{}, policy)
+
+	tests := []struct {
+		role     string
+		id       uint
+		notFound bool
+	}{
+		{"teacher", liveStudentID, false},
+		{"teacher", deletedStudentID, true},
+		{"admin", liveStudentID, false},
+		{"admin", deletedStudentID, false},
+	}
+	for _, tt := range tests {
+		ctx := logging.AddPrincipalToContext(context.Background(), &domain.Principal{Subject: tt.role, Roles: []string{tt.role}})
+		_, err := service.GetStudentHistory(ctx, tt.id, domain.HistoryQuery{})
+		if got := errors.Is(err, domain.ErrNotFound); got != tt.notFound || (!tt.notFound && err != nil) {
+			t.Errorf("%s reading history of student %d: error = %v, want not found %v", tt.role, tt.id, err, tt.notFound)
+		}
+		_, err = service.GetStudentAsOf(ctx, tt.id, time.Now().Add(-time.Hour), false)
+		if got := errors.Is(err, domain.ErrNotFound); got != tt.notFound || (!tt.notFound && err != nil) {
+			t.Errorf("%s reading student %d as of an hour ago: error = %v, want not found %v", tt.role, tt.id, err, tt.notFound)
+		}
+	}
+}
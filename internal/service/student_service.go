@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"student-api/internal/domain"
+	"time"
 )
 
 type studentService struct {
@@ -14,21 +16,102 @@ func NewStudentService(repo domain.StudentRepository) domain.StudentService {
 }
 
 func (s *studentService) CreateStudent(ctx context.Context, student *domain.Student) error {
-	return s.repo.Create(student)
+	if err := validateStudent(student); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, student)
 }
 
-func (s *studentService) GetStudent(ctx context.Context, id uint) (*domain.Student, error) {
-	return s.repo.GetByID(id)
+func (s *studentService) GetStudent(ctx context.Context, id uint, includeDeleted bool) (*domain.Student, error) {
+	return s.repo.GetByID(ctx, id, includeDeleted)
 }
 
-func (s *studentService) GetAllStudents(ctx context.Context) ([]domain.Student, error) {
-	return s.repo.GetAll()
+func (s *studentService) GetAllStudents(ctx context.Context, query domain.StudentQuery) (*domain.StudentPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = domain.DefaultStudentPageSize
+	}
+	if query.Limit > domain.MaxStudentPageSize {
+		query.Limit = domain.MaxStudentPageSize
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+	return s.repo.GetAll(ctx, query)
+}
+
+func (s *studentService) ExportStudents(ctx context.Context, query domain.StudentQuery, fn func(*domain.Student) error) error {
+	return s.repo.Stream(ctx, query.Filter, query.Sort, fn)
 }
 
 func (s *studentService) UpdateStudent(ctx context.Context, student *domain.Student) error {
-	return s.repo.Update(student)
+	if err := validateStudent(student); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, student)
+}
+
+func validateStudent(student *domain.Student) error {
+	student.Normalize()
+	if fieldErrs := domain.ValidateStudent(student); len(fieldErrs) > 0 {
+		return domain.NewStudentValidationError(fieldErrs)
+	}
+	return nil
+}
+
+// PatchStudent applies patch to the stored student's writable fields,
+// re-validates the result and writes only the columns that changed. The
+// write is conditional on the version that was read, so a concurrent update
+// between the read and the write fails instead of being overwritten.
+func (s *studentService) PatchStudent(ctx context.Context, id uint, ifVersion uint64, patch domain.StudentPatch) (*domain.Student, error) {
+	current, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if ifVersion != 0 && current.Version != ifVersion {
+		return nil, domain.NewPreconditionFailedError("student %d has been modified since it was read", id)
+	}
+
+	document, err := current.WritableJSON()
+	if err != nil {
+		return nil, err
+	}
+	patched, err := patch(document)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := domain.ParseStudentJSON(patched)
+	if err != nil {
+		if errors.Is(err, domain.ErrMalformedJSON) {
+			return nil, domain.NewValidationError(err, "patched document must be a JSON object")
+		}
+		return nil, err
+	}
+
+	changes := domain.DiffStudent(current, updated)
+	if changes.IsEmpty() {
+		return current, nil
+	}
+	updatedAt, version, err := s.repo.UpdateFields(ctx, id, current.Version, changes)
+	if err != nil {
+		return nil, err
+	}
+	changes.Apply(current)
+	current.UpdatedAt = updatedAt
+	current.Version = version
+	return current, nil
 }
 
 func (s *studentService) DeleteStudent(ctx context.Context, id uint) error {
-	return s.repo.Delete(id)
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *studentService) RestoreStudent(ctx context.Context, id uint) (*domain.Student, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id, false)
+}
+
+func (s *studentService) PurgeDeletedStudents(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeleted(ctx, time.Now().Add(-retention))
 }
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"student-api/internal/domain"
+	"student-api/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("service")
+
+type tracedStudentService struct {
+	next domain.StudentService
+}
+
+// NewTracedStudentService runs each call to next in a span named after the
+// method, e.g. "StudentService.GetStudent".
+func NewTracedStudentService(next domain.StudentService) domain.StudentService {
+	return &tracedStudentService{next: next}
+}
+
+func (s *tracedStudentService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "StudentService."+method, trace.WithAttributes(attrs...))
+}
+
+func studentIDAttr(id uint) attribute.KeyValue {
+	return attribute.Int64("student.id", int64(id))
+}
+
+func (s *tracedStudentService) CreateStudent(ctx context.Context, student *domain.Student) (err error) {
+	ctx, span := s.start(ctx, "CreateStudent")
+	defer func() {
+		span.SetAttributes(studentIDAttr(student.ID))
+		tracing.End(span, err)
+	}()
+	return s.next.CreateStudent(ctx, student)
+}
+
+func (s *tracedStudentService) GetStudent(ctx context.Context, id uint, includeDeleted bool) (_ *domain.Student, err error) {
+	ctx, span := s.start(ctx, "GetStudent", studentIDAttr(id), attribute.Bool("student.include_deleted", includeDeleted))
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetStudent(ctx, id, includeDeleted)
+}
+
+func (s *tracedStudentService) GetAllStudents(ctx context.Context, query domain.StudentQuery) (_ *domain.StudentPage, err error) {
+	ctx, span := s.start(ctx, "GetAllStudents", attribute.Int("query.limit", query.Limit), attribute.Int("query.offset", query.Offset))
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetAllStudents(ctx, query)
+}
+
+func (s *tracedStudentService) ExportStudents(ctx context.Context, query domain.StudentQuery, fn func(*domain.Student) error) (err error) {
+	ctx, span := s.start(ctx, "ExportStudents")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ExportStudents(ctx, query, fn)
+}
+
+func (s *tracedStudentService) UpdateStudent(ctx context.Context, student *domain.Student) (err error) {
+	ctx, span := s.start(ctx, "UpdateStudent", studentIDAttr(student.ID))
+	defer func() { tracing.End(span, err) }()
+	return s.next.UpdateStudent(ctx, student)
+}
+
+func (s *tracedStudentService) PatchStudent(ctx context.Context, id uint, ifVersion uint64, patch domain.StudentPatch) (_ *domain.Student, err error) {
+	ctx, span := s.start(ctx, "PatchStudent", studentIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return s.next.PatchStudent(ctx, id, ifVersion, patch)
+}
+
+func (s *tracedStudentService) DeleteStudent(ctx context.Context, id uint) (err error) {
+	ctx, span := s.start(ctx, "DeleteStudent", studentIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return s.next.DeleteStudent(ctx, id)
+}
+
+func (s *tracedStudentService) GetStudentAsOf(ctx context.Context, id uint, asOf time.Time, includeDeleted bool) (_ *domain.Student, err error) {
+	ctx, span := s.start(ctx, "GetStudentAsOf", studentIDAttr(id), attribute.String("student.as_of", asOf.UTC().Format(time.RFC3339)))
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetStudentAsOf(ctx, id, asOf, includeDeleted)
+}
+
+func (s *tracedStudentService) GetStudentHistory(ctx context.Context, id uint, query domain.HistoryQuery) (_ *domain.HistoryPage, err error) {
+	ctx, span := s.start(ctx, "GetStudentHistory", studentIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetStudentHistory(ctx, id, query)
+}
+
+func (s *tracedStudentService) RestoreStudent(ctx context.Context, id uint) (_ *domain.Student, err error) {
+	ctx, span := s.start(ctx, "RestoreStudent", studentIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+	return s.next.RestoreStudent(ctx, id)
+}
+
+func (s *tracedStudentService) PurgeDeletedStudents(ctx context.Context, retention time.Duration) (n int64, err error) {
+	ctx, span := s.start(ctx, "PurgeDeletedStudents")
+	defer func() {
+		span.SetAttributes(attribute.Int64("students.purged", n))
+		tracing.End(span, err)
+	}()
+	return s.next.PurgeDeletedStudents(ctx, retention)
+}
+
+func (s *tracedStudentService) ExecuteBatch(ctx context.Context, mode domain.BatchMode, ops []domain.BatchOperation) (_ []domain.BatchResult, err error) {
+	ctx, span := s.start(ctx, "ExecuteBatch", attribute.String("batch.mode", string(mode)), attribute.Int("batch.operations", len(ops)))
+	defer func() { tracing.End(span, err) }()
+	return s.next.ExecuteBatch(ctx, mode, ops)
+}
+
+func (s *tracedStudentService) ImportStudents(ctx context.Context, rows domain.StudentRows, dryRun bool) (_ *domain.ImportReport, err error) {
+	ctx, span := s.start(ctx, "ImportStudents", attribute.Bool("import.dry_run", dryRun))
+	defer func() { tracing.End(span, err) }()
+	return s.next.ImportStudents(ctx, rows, dryRun)
+}
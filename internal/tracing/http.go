@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader carries the trace id for callers that do not send
+// traceparent; it is also set on every response.
+const TraceIDHeader = "X-Trace-ID"
+
+// Extract returns ctx with the remote span context of the request: the
+// traceparent and tracestate headers, or else a trace id in X-Trace-ID as
+// 32 hex digits or a UUID. Spans started from it continue that trace.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	traceID, err := trace.TraceIDFromHex(strings.ReplaceAll(strings.TrimSpace(header.Get(TraceIDHeader)), "-", ""))
+	if err != nil {
+		return ctx
+	}
+	// A span context needs a parent span id; the caller did not send one,
+	// so a random one stands in for it.
+	var spanID trace.SpanID
+	rand.Read(spanID[:])
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Remote:  true,
+	}))
+}
+
+// Inject sets traceparent and tracestate for the span in ctx on header.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceID returns the hex trace id of the span in ctx, or "" if there is
+// none.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.TraceID().IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,145 @@
+// Package tracing sets up OpenTelemetry tracing and W3C trace context
+// propagation, and holds the helpers the other layers use to create spans.
+//
+// Spans are exported over OTLP/HTTP, configured by the standard
+// OTEL_EXPORTER_OTLP_* environment variables, or written as JSON lines to a
+// file, which is meant for tests and local debugging.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"student-api/internal/domain"
+	"student-api/internal/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies this service in exported spans.
+const ServiceName = "student-api"
+
+// Exporters accepted in Config.Exporter.
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+type Config struct {
+	// Exporter is "otlp", "file" or "none". With "none" trace ids are still
+	// generated and propagated, but spans are discarded.
+	Exporter string
+	// File is the path spans are appended to with the file exporter.
+	File string
+	// SampleRatio is the share of new traces that are recorded; requests
+	// continuing a trace follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and the W3C traceparent and
+// tracestate propagator. The returned function flushes and stops the
+// exporter.
+func Setup(cfg Config) (shutdown func(context.Context) error, err error) {
+	var opts []sdktrace.TracerProviderOption
+	var closeFile func() error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("create file exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+		closeFile = f.Close
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, must be %s, %s or %s", cfg.Exporter, ExporterOTLP, ExporterFile, ExporterNone)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", ServiceName),
+		attribute.String("service.version", version.Get().Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+	// A remote parent that is not sampled may just be an X-Trace-ID from a
+	// caller without tracing, so it gets the same chance as a new trace.
+	root := sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	opts = append(opts,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(root, sdktrace.WithRemoteParentNotSampled(root))),
+	)
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			err = errors.Join(err, closeFile())
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the tracer for the instrumented package, named after its
+// import path.
+func Tracer(pkg string) trace.Tracer {
+	return otel.Tracer("student-api/internal/" + pkg)
+}
+
+// End ends span, recording err if it is set. Errors caused by the request,
+// such as validation or not found errors, are recorded without marking the
+// span failed.
+func End(span trace.Span, err error) {
+	if err != nil {
+		message := errorMessage(err)
+		// The event span.RecordError would add, but with the safe message
+		span.AddEvent("exception", trace.WithAttributes(
+			attribute.String("exception.type", fmt.Sprintf("%T", err)),
+			attribute.String("exception.message", message),
+		))
+		if !isClientError(err) {
+			span.SetStatus(codes.Error, message)
+		}
+	}
+	span.End()
+}
+
+// errorMessage describes err without personal data: a domain error by its
+// kind and client-safe message, leaving out the wrapped driver error, and
+// any other error with the email addresses in its text removed.
+func errorMessage(err error) string {
+	var de *domain.Error
+	if errors.As(err, &de) {
+		return fmt.Sprintf("%v: %s", de.Kind, de.Message)
+	}
+	return domain.ReplaceEmails(err.Error(), func(string) string { return "[redacted]" })
+}
+
+func isClientError(err error) bool {
+	var de *domain.Error
+	return errors.As(err, &de) && !errors.Is(err, domain.ErrUnavailable)
+}
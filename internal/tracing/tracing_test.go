@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"student-api/internal/domain"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func endSpan(t *testing.T, err error) sdktrace.ReadOnlySpan {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := provider.Tracer("test").Start(context.Background(), "op")
+	End(span, err)
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans ended, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+// recorded returns the span status and exception events as one string.
+func recorded(span sdktrace.ReadOnlySpan) string {
+	text := span.Status().Description
+	for _, event := range span.Events() {
+		for _, attr := range event.Attributes {
+			text += " " + attr.Value.Emit()
+		}
+	}
+	return text
+}
+
+func TestEndLeavesEmailsOutOfSpans(t *testing.T) {
+	driverErr := fmt.Errorf("Error 1062 (23000): Duplicate entry 'alice@example.com' for key 'students.email'")
+
+	span := endSpan(t, driverErr)
+	if span.Status().Code != codes.Error {
+		t.Errorf("status = %v, want error", span.Status())
+	}
+	if text := recorded(span); strings.Contains(text, "alice@example.com") || !strings.Contains(text, "Duplicate entry '[redacted]'") {
+		t.Errorf("driver error recorded as %q", text)
+	}
+
+	span = endSpan(t, domain.NewConflictError(driverErr, "a student with this email already exists"))
+	if span.Status().Code == codes.Error {
+		t.Errorf("client error marked the span failed: %v", span.Status())
+	}
+	if text := recorded(span); strings.Contains(text, "Duplicate entry") || !strings.Contains(text, "conflict: a student with this email already exists") {
+		t.Errorf("domain error recorded as %q", text)
+	}
+}
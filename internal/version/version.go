@@ -0,0 +1,42 @@
+// Package version reports the build version of the binary.
+//
+// Release builds set the values with
+//
+//	go build -ldflags "-X student-api/internal/version.Version=v1.2.3 -X student-api/internal/version.Commit=abc123"
+//
+// Otherwise they are derived from the module and VCS build info.
+package version
+
+import "runtime/debug"
+
+var (
+	Version = ""
+	Commit  = ""
+)
+
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+}
+
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit}
+	if info.Version != "" && info.Commit != "" {
+		return info
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			if setting.Key == "vcs.revision" && info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
@@ -0,0 +1,226 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned when no queue slot frees up within the
+	// enqueue timeout.
+	ErrQueueFull = errors.New("worker pool queue is full")
+	// ErrClosed is returned for jobs submitted after Shutdown.
+	ErrClosed = errors.New("worker pool is shut down")
+)
+
+// Observer receives timing events for every job. Implementations must be
+// safe for concurrent use.
+type Observer interface {
+	JobRejected()
+	JobStarted(wait time.Duration)
+	JobFinished(duration time.Duration)
+}
+
+type Config struct {
+	Workers   int
+	QueueSize int
+	// EnqueueTimeout is how long Submit waits for a free queue slot before
+	// giving up. Zero rejects immediately when the queue is full.
+	EnqueueTimeout time.Duration
+	Observer       Observer
+}
+
+// Stats is a point-in-time snapshot of pool activity.
+type Stats struct {
+	Workers       int
+	BusyWorkers   int
+	QueueLength   int
+	QueueCapacity int
+	Submitted     uint64
+	Rejected      uint64
+	Completed     uint64
+	AvgWait       time.Duration
+	AvgDuration   time.Duration
+}
+
+type job struct {
+	fn       func()
+	queuedAt time.Time
+}
+
+// Pool runs submitted jobs on a fixed number of goroutines fed by a
+// bounded queue, rejecting work instead of blocking when it is saturated.
+type Pool struct {
+	cfg  Config
+	jobs chan job
+	wg   sync.WaitGroup
+	mu   sync.RWMutex
+
+	closed    bool
+	busy      atomic.Int64
+	submitted atomic.Uint64
+	rejected  atomic.Uint64
+	completed atomic.Uint64
+	waitNanos atomic.Int64
+	runNanos  atomic.Int64
+}
+
+func New(cfg Config) *Pool {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	}
+	if cfg.Observer == nil {
+		cfg.Observer = noopObserver{}
+	}
+
+	p := &Pool{
+		cfg:  cfg,
+		jobs: make(chan job, cfg.QueueSize),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+	for j := range p.jobs {
+		wait := time.Since(j.queuedAt)
+		p.waitNanos.Add(int64(wait))
+		p.cfg.Observer.JobStarted(wait)
+
+		p.busy.Add(1)
+		start := time.Now()
+		run(j.fn)
+		duration := time.Since(start)
+		p.busy.Add(-1)
+
+		p.runNanos.Add(int64(duration))
+		p.completed.Add(1)
+		p.cfg.Observer.JobFinished(duration)
+	}
+}
+
+// run calls fn, logging a panic instead of letting it crash the process.
+// Jobs that someone waits for must recover themselves to report the
+// failure; a panic reaching here only ends the job.
+func run(fn func()) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.Error("worker pool job panicked", "panic", recovered, "stack", string(debug.Stack()))
+		}
+	}()
+	fn()
+}
+
+// Submit queues fn for execution. It returns ErrQueueFull if the queue
+// stays full for the configured enqueue timeout and ErrClosed after
+// Shutdown has been called.
+func (p *Pool) Submit(fn func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	j := job{fn: fn, queuedAt: time.Now()}
+	select {
+	case p.jobs <- j:
+		p.submitted.Add(1)
+		return nil
+	default:
+	}
+
+	if p.cfg.EnqueueTimeout > 0 {
+		timer := time.NewTimer(p.cfg.EnqueueTimeout)
+		defer timer.Stop()
+		select {
+		case p.jobs <- j:
+			p.submitted.Add(1)
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	p.rejected.Add(1)
+	p.cfg.Observer.JobRejected()
+	return ErrQueueFull
+}
+
+// Shutdown stops accepting new jobs, lets the workers drain everything
+// already queued and waits for them to exit or for ctx to expire.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("worker pool drain: %w", ctx.Err())
+	}
+}
+
+// Workers returns the number of worker goroutines in the pool.
+func (p *Pool) Workers() int {
+	return p.cfg.Workers
+}
+
+// BusyWorkers returns the number of workers currently running a job.
+func (p *Pool) BusyWorkers() int {
+	return int(p.busy.Load())
+}
+
+// QueueLength returns the number of jobs waiting for a free worker.
+func (p *Pool) QueueLength() int {
+	return len(p.jobs)
+}
+
+// QueueCapacity returns the size of the job queue buffer.
+func (p *Pool) QueueCapacity() int {
+	return cap(p.jobs)
+}
+
+func (p *Pool) Stats() Stats {
+	s := Stats{
+		Workers:       p.Workers(),
+		BusyWorkers:   p.BusyWorkers(),
+		QueueLength:   p.QueueLength(),
+		QueueCapacity: p.QueueCapacity(),
+		Submitted:     p.submitted.Load(),
+		Rejected:      p.rejected.Load(),
+		Completed:     p.completed.Load(),
+	}
+	if s.Completed > 0 {
+		s.AvgWait = time.Duration(p.waitNanos.Load() / int64(s.Completed))
+		s.AvgDuration = time.Duration(p.runNanos.Load() / int64(s.Completed))
+	}
+	return s
+}
+
+type noopObserver struct{}
+
+func (noopObserver) JobRejected()              {}
+func (noopObserver) JobStarted(time.Duration)  {}
+func (noopObserver) JobFinished(time.Duration) {}
@@ -0,0 +1,128 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockWorkers occupies every worker of p until the returned func is
+// called.
+func blockWorkers(t *testing.T, p *Pool) (release func()) {
+	t.Helper()
+	unblock := make(chan struct{})
+	started := make(chan struct{})
+	for i := 0; i < p.Workers(); i++ {
+		if err := p.Submit(func() {
+			started <- struct{}{}
+			<-unblock
+		}); err != nil {
+			t.Fatal(err)
+		}
+		<-started
+	}
+	return func() { close(unblock) }
+}
+
+func TestSubmitQueueFull(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 1})
+	defer p.Shutdown(context.Background())
+	release := blockWorkers(t, p)
+	defer release()
+
+	if err := p.Submit(func() {}); err != nil {
+		t.Fatalf("submit into free queue slot: %v", err)
+	}
+	start := time.Now()
+	if err := p.Submit(func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("submit into full queue: %v, want ErrQueueFull", err)
+	}
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Errorf("rejection without enqueue timeout took %v", waited)
+	}
+	if stats := p.Stats(); stats.Rejected != 1 || stats.Submitted != 2 {
+		t.Errorf("stats = %+v, want 2 submitted and 1 rejected", stats)
+	}
+}
+
+func TestSubmitEnqueueTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	p := New(Config{Workers: 1, QueueSize: 1, EnqueueTimeout: timeout})
+	defer p.Shutdown(context.Background())
+	release := blockWorkers(t, p)
+	if err := p.Submit(func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := p.Submit(func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("submit into full queue: %v, want ErrQueueFull", err)
+	}
+	if waited := time.Since(start); waited < timeout {
+		t.Errorf("rejected after %v, want at least the %v enqueue timeout", waited, timeout)
+	}
+
+	// A slot freeing up within the timeout accepts the job.
+	time.AfterFunc(timeout/5, release)
+	if err := p.Submit(func() {}); err != nil {
+		t.Errorf("submit while a slot frees up: %v", err)
+	}
+}
+
+func TestShutdownDrainsQueuedJobs(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 10})
+	release := blockWorkers(t, p)
+	var ran atomic.Int32
+	for i := 0; i < 10; i++ {
+		if err := p.Submit(func() { ran.Add(1) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.AfterFunc(10*time.Millisecond, release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := ran.Load(); n != 10 {
+		t.Errorf("%d queued jobs ran before Shutdown returned, want 10", n)
+	}
+	if err := p.Submit(func() {}); !errors.Is(err, ErrClosed) {
+		t.Errorf("submit after shutdown: %v, want ErrClosed", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 1})
+	release := blockWorkers(t, p)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown with a stuck job: %v, want deadline exceeded", err)
+	}
+}
+
+func TestPanickingJobDoesNotStopWorker(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 2})
+	if err := p.Submit(func() { panic("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	if err := p.Submit(func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job after a panicking one did not run")
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if completed := p.Stats().Completed; completed != 2 {
+		t.Errorf("completed = %d, want 2", completed)
+	}
+}
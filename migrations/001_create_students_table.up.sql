@@ -1,7 +1,3 @@
-CREATE DATABASE IF NOT EXISTS student_db;
-
-USE student_db;
-
 CREATE TABLE IF NOT EXISTS students (
     id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
     first_name VARCHAR(50) NOT NULL,
@@ -0,0 +1 @@
+ALTER TABLE students ADD COLUMN version BIGINT UNSIGNED NOT NULL DEFAULT 1 AFTER grade;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    -- SHA-256 of method, path and the client's Idempotency-Key
+    idem_key CHAR(64) NOT NULL PRIMARY KEY,
+    fingerprint CHAR(64) NOT NULL,
+    -- NULL while the first request is still being processed
+    status_code SMALLINT NULL,
+    headers JSON NULL,
+    body MEDIUMBLOB NULL,
+    locked_until TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    INDEX idx_idempotency_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
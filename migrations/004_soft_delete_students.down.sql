@@ -0,0 +1,7 @@
+-- Fails if the email of a soft-deleted student has since been reused.
+ALTER TABLE students
+    DROP INDEX idx_students_deleted_at,
+    DROP INDEX uq_students_active_email,
+    DROP COLUMN active_email,
+    DROP COLUMN deleted_at,
+    ADD UNIQUE INDEX email (email);
@@ -0,0 +1,9 @@
+-- Soft-deleted students keep their email, so uniqueness only applies to
+-- live rows: active_email is NULL once deleted_at is set, and a UNIQUE
+-- index allows any number of NULLs.
+ALTER TABLE students
+    ADD COLUMN deleted_at TIMESTAMP NULL AFTER updated_at,
+    ADD COLUMN active_email VARCHAR(100) GENERATED ALWAYS AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    DROP INDEX email,
+    ADD UNIQUE INDEX uq_students_active_email (active_email),
+    ADD INDEX idx_students_deleted_at (deleted_at);
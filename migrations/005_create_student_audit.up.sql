@@ -0,0 +1,16 @@
+-- One row per write to a student. There is no foreign key so the trail
+-- outlives students removed by the retention purge.
+CREATE TABLE IF NOT EXISTS student_audit (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    student_id BIGINT UNSIGNED NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    trace_id VARCHAR(64) NULL,
+    -- Student version after the write
+    version BIGINT UNSIGNED NOT NULL,
+    -- {"field": {"from": ..., "to": ...}} for every field the write changed
+    changes JSON NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_student_audit_student (student_id, id),
+    INDEX idx_student_audit_created_at (student_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    -- Public part of the key, also shown in logs
+    id VARCHAR(32) NOT NULL PRIMARY KEY,
+    -- SHA-256 of the secret part; the secret itself is never stored
+    key_hash CHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    owner VARCHAR(255) NOT NULL,
+    scopes JSON NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1 @@
+-- Scrubbed personal data cannot be restored; nothing to revert.
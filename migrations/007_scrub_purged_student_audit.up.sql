@@ -0,0 +1,8 @@
+-- Blank the names and email in the audit trail of students purged before
+-- the purge started doing so itself.
+UPDATE student_audit
+SET changes = JSON_REPLACE(changes,
+    '$.firstName', JSON_OBJECT('from', NULL, 'to', NULL),
+    '$.lastName', JSON_OBJECT('from', NULL, 'to', NULL),
+    '$.email', JSON_OBJECT('from', NULL, 'to', NULL))
+WHERE NOT EXISTS (SELECT 1 FROM students WHERE students.id = student_audit.student_id);
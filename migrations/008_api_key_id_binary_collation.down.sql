@@ -0,0 +1 @@
+ALTER TABLE api_keys MODIFY id VARCHAR(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL;
@@ -0,0 +1,2 @@
+-- Key ids are case-sensitive base64url, so look them up by exact match.
+ALTER TABLE api_keys MODIFY id VARCHAR(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL;
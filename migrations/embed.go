@@ -0,0 +1,11 @@
+// Package migrations embeds the versioned SQL schema migrations so the
+// binary can apply them without the files being present at runtime.
+//
+// Files are named NNN_description.up.sql with an optional matching
+// NNN_description.down.sql that reverts it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS